@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+func accessCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	context := fs.String("context", "", "List access in `context` rather than the current context")
+	namespaces := fs.String("namespace", "", "Comma separated `namespaces` to list access in, rather than the "+
+		"namespaces of your teams")
+	output := choiceFlag(fs, "output", "table", "Output `format`, table or json", "table", "json")
+	return func(args []string) error {
+		cmd := findCommand("access")
+		if err := cmd.expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		env, err := currentEnv(*context)
+		if err != nil {
+			return err
+		}
+		accessContext := *context
+		if accessContext == "" {
+			accessContext = util.EnvToContext(env)
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		if *namespaces != "" {
+			names = strings.Split(*namespaces, ",")
+		} else {
+			token, err := authenticator.Token(ctx, env)
+			if err != nil {
+				return err
+			}
+			teams, err := tokenTeams(authenticator, token)
+			if err != nil {
+				return err
+			}
+			names = teamNamespaces(authenticator.Config.TeamNamespaceFor(env), teams)
+			if len(names) == 0 {
+				return fmt.Errorf("no team found in groups of your token - use --namespace to list access in " +
+					"other namespaces")
+			}
+		}
+
+		access, err := authenticator.ReviewAccess(ctx, accessContext, names)
+		if err != nil {
+			return err
+		}
+		if *output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(access)
+		}
+		return printAccess(os.Stdout, access)
+	}
+}
+
+// printAccess prints a table of the rules of each namespace in access, with resources in the resource.group form used
+// by kubectl
+func printAccess(w io.Writer, access []kubelogin.NamespaceAccess) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAMESPACE\tRESOURCES\tVERBS")
+	for _, namespaceAccess := range access {
+		if len(namespaceAccess.Rules) == 0 {
+			_, _ = fmt.Fprintf(tw, "%v\t<none>\t\n", namespaceAccess.Namespace)
+		}
+		for _, rule := range namespaceAccess.Rules {
+			_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\n", namespaceAccess.Namespace, ruleResources(rule),
+				strings.Join(rule.Verbs, ","))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, namespaceAccess := range access {
+		if namespaceAccess.Incomplete {
+			_, _ = fmt.Fprintf(w, "\nThe API server could not tell all rules of %v, only common resources were "+
+				"checked", namespaceAccess.Namespace)
+			if namespaceAccess.EvaluationError != "" {
+				_, _ = fmt.Fprintf(w, ": %v", namespaceAccess.EvaluationError)
+			}
+			_, _ = fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+func ruleResources(rule kubelogin.AccessRule) string {
+	if len(rule.NonResourceURLs) > 0 {
+		return strings.Join(rule.NonResourceURLs, ",")
+	}
+	groups := rule.APIGroups
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	var resources []string
+	for _, group := range groups {
+		for _, resource := range rule.Resources {
+			if group != "" {
+				resource += "." + group
+			}
+			if len(rule.ResourceNames) > 0 {
+				resource += "[" + strings.Join(rule.ResourceNames, ",") + "]"
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return strings.Join(resources, ",")
+}
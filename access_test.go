@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+)
+
+func TestPrintAccessTable(t *testing.T) {
+	access := []kubelogin.NamespaceAccess{
+		{Namespace: "cool-runners", Rules: []kubelogin.AccessRule{
+			{Verbs: []string{"get", "list"}, APIGroups: []string{"", "apps"},
+				Resources: []string{"pods", "deployments"}},
+			{Verbs: []string{"get"}, Resources: []string{"configmaps"}, ResourceNames: []string{"app-config"}},
+		}},
+		{Namespace: "lunatics", Incomplete: true},
+	}
+	var out bytes.Buffer
+	if err := printAccess(&out, access); err != nil {
+		t.Fatal(err)
+	}
+	expected := `NAMESPACE     RESOURCES                                    VERBS
+cool-runners  pods,deployments,pods.apps,deployments.apps  get,list
+cool-runners  configmaps[app-config]                       get
+lunatics      <none>                                       
+
+The API server could not tell all rules of lunatics, only common resources were checked
+`
+	if strings.TrimSpace(out.String()) != strings.TrimSpace(expected) {
+		t.Errorf("Expected\n%v\nbut was\n%v", expected, out.String())
+	}
+}
+
+func TestTeamNamespaces(t *testing.T) {
+	namespaces := teamNamespaces("{name}", []string{"team-cool-runners", "team-lunatics"})
+	if strings.Join(namespaces, ",") != "cool-runners,lunatics" {
+		t.Errorf("Unexpected namespaces %v", namespaces)
+	}
+}
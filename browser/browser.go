@@ -0,0 +1,108 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/skratchdot/open-golang/open"
+)
+
+const (
+	// None only prints the authorization URL, e.g. for SSH sessions with port forwarding
+	None = "none"
+	// Default opens the URL with the system browser
+	Default = "default"
+)
+
+// Launcher opens the authorization URL for the user to authenticate
+type Launcher interface {
+	Open(url string) error
+}
+
+// LauncherFunc allows any function with the right signature to be used as a Launcher
+type LauncherFunc func(url string) error
+
+// Open calls f(url)
+func (f LauncherFunc) Open(url string) error {
+	return f(url)
+}
+
+// ErrNoBrowser is returned by the "none" launcher to signal that the URL should be printed instead
+var ErrNoBrowser = errors.New("browser disabled")
+
+// FromSpec returns the launcher described by spec, which is one of:
+//   - "none" - don't open any browser
+//   - "" or "default" - open with the system browser
+//   - a command template containing %s, like "firefox -P work --new-window %s"
+//   - an application name, like "Google Chrome" or "Safari" (note case sensitivity)
+func FromSpec(spec string) Launcher {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == None:
+		return LauncherFunc(func(string) error { return ErrNoBrowser })
+	case spec == "" || spec == Default:
+		return LauncherFunc(open.Run)
+	case strings.Contains(spec, "%s"):
+		return commandTemplate(spec)
+	default:
+		return LauncherFunc(func(url string) error { return open.RunWith(url, spec) })
+	}
+}
+
+// Open launches url using launcher. Should that fail, or if no browser is wanted, the URL is printed to out (normally
+// stderr, as stdout is reserved for the exec credential) for the user to open manually.
+func Open(launcher Launcher, url string, out io.Writer) {
+	err := launcher.Open(url)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, ErrNoBrowser) {
+		_, _ = fmt.Fprintf(out, "Failed opening web browser: %v\n", err)
+	}
+	_, _ = fmt.Fprintf(out, "Open the following URL in your browser to authenticate:\n\n    %v\n\n", url)
+}
+
+func commandTemplate(template string) Launcher {
+	return LauncherFunc(func(url string) error {
+		args := SplitCommand(template)
+		for i, arg := range args {
+			args[i] = strings.ReplaceAll(arg, "%s", url)
+		}
+		// Don't wait for the browser to exit - it might very well be the process that stays around
+		return exec.Command(args[0], args[1:]...).Start() //nolint:gosec
+	})
+}
+
+// SplitCommand splits a command line into arguments on whitespace, keeping single or double quoted strings together
+func SplitCommand(command string) (args []string) {
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
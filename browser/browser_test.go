@@ -0,0 +1,40 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandKeepsQuotedArgumentsTogether(t *testing.T) {
+	args := SplitCommand(`firefox -P "work profile" --new-window '%s'`)
+	expected := []string{"firefox", "-P", "work profile", "--new-window", "%s"}
+
+	if !reflect.DeepEqual(expected, args) {
+		t.Errorf("Expected args to be %v but was %v", expected, args)
+	}
+}
+
+func TestNoneLauncherPrintsURL(t *testing.T) {
+	out := &bytes.Buffer{}
+	Open(FromSpec(None), "https://login.example.com/authorize", out)
+
+	if !strings.Contains(out.String(), "https://login.example.com/authorize") {
+		t.Errorf("Expected URL to be printed, got %v", out.String())
+	}
+	if strings.Contains(out.String(), "Failed") {
+		t.Errorf("Expected no failure to be reported in none mode, got %v", out.String())
+	}
+}
+
+func TestFailingLauncherFallsBackToPrintingURL(t *testing.T) {
+	out := &bytes.Buffer{}
+	failing := LauncherFunc(func(string) error { return errors.New("no display") })
+	Open(failing, "https://login.example.com/authorize", out)
+
+	if !strings.Contains(out.String(), "no display") || !strings.Contains(out.String(), "login.example.com") {
+		t.Errorf("Expected failure and URL to be printed, got %v", out.String())
+	}
+}
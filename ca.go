@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func caCmd(_ context.Context, fs *flag.FlagSet) func([]string) error {
+	kubeconfig := fs.String("kubeconfig", "", "Update CA in `file` rather than ~/.kube/config.<env> (update)")
+	fromFile := fs.String("from-file", "", "Read the new PEM encoded CA from `file` rather than fetching it from "+
+		"the cluster (update)")
+	caFingerprint := fs.String("ca-fingerprint", "", "Expected SHA-256 `fingerprint` of the new CA, rather than "+
+		"confirming it (update)")
+	dryRun := fs.Bool("dry-run", false, "Print a diff of what would change, without writing anything (update)")
+	return func(args []string) error {
+		cmd := findCommand("ca")
+		if err := cmd.expectArgs(args, 1, 2); err != nil {
+			return err
+		}
+		envs := util.KnownEnvironments()
+		if len(args) > 1 {
+			if !isKnownEnv(args[1]) {
+				return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown environment %q", args[1])}
+			}
+			envs = args[1:]
+		}
+		switch args[0] {
+		case "list":
+			loginCfg, err := util.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed reading %v: %w", util.ConfigFile(), err)
+			}
+			return listCAs(envs, time.Now(), loginCfg.CAExpiryWarning())
+		case "verify":
+			authenticator, err := newAuthenticator("")
+			if err != nil {
+				return err
+			}
+			return verifyCAs(envs, authenticator.ClusterProxyURL)
+		case "update":
+			if len(args) != 2 {
+				return &usageError{command: cmd.name, msg: "ca update requires argument <env>"}
+			}
+			target := *kubeconfig
+			if target == "" {
+				target = clientcmd.RecommendedHomeFile + "." + args[1]
+			}
+			loginCfg, err := util.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed reading %v: %w", util.ConfigFile(), err)
+			}
+			return updateCA(args[1], target, *fromFile, *caFingerprint, loginCfg.Env(args[1]).ProxyURL, *dryRun)
+		}
+		return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown ca command %q, use %v", args[0], cmd.args)}
+	}
+}
+
+// listCAs prints subject and expiry of the CA trusted for each of envs
+func listCAs(envs []string, now time.Time, warning time.Duration) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ENV\tCONTEXT\tSOURCE\tSUBJECT\tEXPIRES\tSTATUS")
+	for _, env := range envs {
+		ctx := util.EnvToContext(env)
+		certs, source, err := trustedCAs(ctx)
+		if err != nil {
+			_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t\t\t%v\n", env, ctx, source, err)
+			continue
+		}
+		for _, cert := range certs {
+			_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n", env, ctx, source, cert.Subject,
+				cert.NotAfter.Format("2006-01-02"), expiryStatus(cert, now, warning))
+		}
+	}
+	return tw.Flush()
+}
+
+// trustedCAs returns the CA certificates trusted for the cluster of context, and where they're from: the CA configured
+// in kubeconf if present, like after a rotation by ca update, and the CA known to kubectl login otherwise
+func trustedCAs(context string) ([]*x509.Certificate, string, error) {
+	if cluster := util.KubeconfCluster(context); cluster != nil && len(cluster.CertificateAuthorityData) > 0 {
+		certs, err := util.ParseCertificates(cluster.CertificateAuthorityData)
+		return certs, "kubeconf", err
+	}
+	certs, err := util.ClusterCaCertificates(context)
+	return certs, "built-in", err
+}
+
+func expiryStatus(cert *x509.Certificate, now time.Time, warning time.Duration) string {
+	switch left := cert.NotAfter.Sub(now); {
+	case left <= 0:
+		return "EXPIRED"
+	case left <= warning:
+		return fmt.Sprintf("expires in %v days", int(left.Hours()/24))
+	}
+	return "ok"
+}
+
+// verifyCAs checks that the certificate chain presented by the API server of each of envs is signed by the trusted CA.
+// API servers are reached through the proxy returned by proxyURLOf, if any.
+func verifyCAs(envs []string, proxyURLOf func(env string) string) error {
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ENV\tSERVER\tRESULT")
+	for _, env := range envs {
+		ctx := util.EnvToContext(env)
+		server := util.ClusterServer(ctx)
+		result := "ok"
+		trusted, _, err := trustedCAs(ctx)
+		if err == nil {
+			var chain []*x509.Certificate
+			if chain, err = util.FetchCertificateChain(server, proxyURLOf(env)); err == nil {
+				if err = util.VerifyChain(chain, trusted); err != nil {
+					err = fmt.Errorf("%v (presented CA fingerprint %v)", err,
+						util.Fingerprint(util.TrustAnchor(chain)))
+				}
+			}
+		}
+		if err != nil {
+			result = "FAILED: " + err.Error()
+			failed++
+		}
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\n", env, server, result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("CA verification failed for %v of %v environments", failed, len(envs))
+	}
+	return nil
+}
+
+// updateCA replaces the certificate-authority-data of env's cluster in file with the CA read from caFile, or the CA
+// presented by the cluster if caFile is empty, once its fingerprint has been confirmed. The cluster is reached through
+// proxyURL if set, or else through the proxy-url of the cluster, if any.
+func updateCA(env, file, caFile, fingerprint, proxyURL string, dryRun bool) error {
+	ctx := util.EnvToContext(env)
+	before, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed reading file %v: %w", file, err)
+	}
+	kubeconf, err := clientcmd.Load(before)
+	if err != nil {
+		return fmt.Errorf("failed parsing file %v: %w", file, err)
+	}
+	clusterName := ctx
+	if contextConf, ok := kubeconf.Contexts[ctx]; ok && contextConf.Cluster != "" {
+		clusterName = contextConf.Cluster
+	}
+	cluster, ok := kubeconf.Clusters[clusterName]
+	if !ok {
+		return fmt.Errorf("no cluster %v in %v - run 'kubectl login init %v' first", clusterName, file, env)
+	}
+
+	var ca *x509.Certificate
+	source := "in " + caFile
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		certs, err := util.ParseCertificates(data)
+		if err != nil {
+			return fmt.Errorf("failed parsing %v: %w", caFile, err)
+		}
+		ca = certs[0]
+	} else {
+		if proxyURL == "" {
+			proxyURL = cluster.ProxyURL
+		}
+		chain, err := util.FetchCertificateChain(cluster.Server, proxyURL)
+		if err != nil {
+			return err
+		}
+		ca, source = util.TrustAnchor(chain), "presented by "+cluster.Server
+	}
+	if len(cluster.CertificateAuthorityData) > 0 && util.ContainsCertificate(cluster.CertificateAuthorityData, ca) {
+		fmt.Printf("CA of %v in %v already up to date\n", env, file)
+		return nil
+	}
+	if err = confirmCertificate(source, ca, fingerprint); err != nil {
+		return err
+	}
+	cluster.CertificateAuthority = ""
+	cluster.CertificateAuthorityData = util.EncodeCertificates(ca)
+	return writeKubeConf(env, file, before, kubeconf, dryRun)
+}
+
+// warnCAExpiry prints a warning if the CA trusted for the cluster of context expires within warning. The CA configured
+// in kubeconf is checked if present, and the CA known to kubectl login otherwise.
+func warnCAExpiry(env, context string, now time.Time, warning time.Duration) {
+	certs, _, _ := trustedCAs(context)
+	for _, cert := range certs {
+		status := expiryStatus(cert, now, warning)
+		if status == "ok" {
+			continue
+		}
+		if status == "EXPIRED" {
+			status = "has expired"
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: CA certificate %v of cluster %v %v (%v). Once the cluster CA has "+
+			"been rotated, run 'kubectl login ca update %v'.\n", cert.Subject, context, status,
+			cert.NotAfter.Format("2006-01-02"), env)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestUpdateCAFromFileReplacesOnlyCertificateAuthorityData(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(caFile, util.EncodeCertificates(server.Certificate()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := util.EnvToContext("qa")
+	kubeconf := api.NewConfig()
+	kubeconf.Clusters[ctx] = &api.Cluster{Server: server.URL, CertificateAuthorityData: []byte("old")}
+	kubeconf.Contexts[ctx] = &api.Context{Cluster: ctx, AuthInfo: ctx, Namespace: "cool-runners"}
+	file := filepath.Join(dir, "config")
+	if err := clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := updateCA("qa", file, caFile, "AB:CD", "", false); err == nil {
+		t.Error("Expected update with mismatching fingerprint to fail")
+	}
+	if err := updateCA("qa", file, caFile, util.Fingerprint(server.Certificate()), "", false); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !util.ContainsCertificate(updated.Clusters[ctx].CertificateAuthorityData, server.Certificate()) {
+		t.Error("Expected CA from file to be stored")
+	}
+	if updated.Contexts[ctx].Namespace != "cool-runners" {
+		t.Error("Expected rest of kubeconf to be kept")
+	}
+}
+
+func TestExpiryStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	cert := server.Certificate()
+	day := 24 * time.Hour
+
+	tests := map[time.Time]string{
+		cert.NotAfter.Add(-100 * day):          "ok",
+		cert.NotAfter.Add(-10*day - time.Hour): "expires in 10 days",
+		cert.NotAfter.Add(time.Hour):           "EXPIRED",
+	}
+	for now, expected := range tests {
+		if status := expiryStatus(cert, now, 30*day); status != expected {
+			t.Errorf("Expected %v at %v, got %v", expected, now, status)
+		}
+	}
+}
+
+func TestVerifyCAsAgainstCAOfKubeconf(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	ctx := util.EnvToContext("qa")
+	kubeconf := api.NewConfig()
+	kubeconf.Clusters[ctx] = &api.Cluster{Server: server.URL,
+		CertificateAuthorityData: util.EncodeCertificates(server.Certificate())}
+	kubeconf.Contexts[ctx] = &api.Context{Cluster: ctx, AuthInfo: ctx}
+	file := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", file)
+
+	// The CA rotated into kubeconf is trusted rather than the one known to kubectl login
+	if err := verifyCAs([]string{"qa"}, func(string) string { return "" }); err != nil {
+		t.Errorf("Expected cluster to verify against the CA in kubeconf, got %v", err)
+	}
+	if _, source, err := trustedCAs(ctx); source != "kubeconf" || err != nil {
+		t.Errorf("Expected CA of kubeconf to be listed, got %v (%v)", source, err)
+	}
+}
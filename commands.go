@@ -0,0 +1,793 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/ghodss/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// command is a kubectl login subcommand. Setup registers the command's flags on fs, and returns the function running
+// the command with the positional arguments remaining after flags have been parsed.
+type command struct {
+	name    string
+	args    string
+	summary string
+	hidden  bool
+	// rawArgs passes all arguments on to the command without parsing flags
+	rawArgs bool
+	setup   func(ctx context.Context, fs *flag.FlagSet) func(args []string) error
+}
+
+// usageError is returned for invalid flags or arguments, and makes main print a hint about --help
+type usageError struct {
+	command string
+	msg     string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+var commands []*command
+
+func init() {
+	// Assigned in init as the help command refers back to the command list
+	commands = []*command{
+		{name: "login", args: "[env]", summary: "Authenticate for the current context, or the given environment " +
+			"(the default command)", setup: loginCmd},
+		{name: "init", args: "<env>[,<env>...]|all", summary: "Initialize or update kubeconf for environments, given " +
+			"by name or glob pattern, or all environments", setup: initCmd},
+		{name: "whoami", summary: "Print details of the current authenticated user (like group membership)",
+			setup: whoamiCmd},
+		{name: "access", summary: "List what you are allowed in the namespaces of your teams, or other namespaces",
+			setup: accessCmd},
+		{name: "team", args: "use <team>", summary: "Switch to a context acting with the rights of one of your " +
+			"teams only", setup: teamCmd},
+		{name: "status", summary: "Print login status of all environments, and which of them share a credential",
+			setup: statusCmd},
+		{name: "logout", summary: "Remove stored token of the current context, and any environment sharing it",
+			setup: logoutCmd},
+		{name: "token", args: "[decode [-]]", summary: "Print the ID token of the current context for other tools, " +
+			"logging in if needed, or decode a token", setup: tokenCmd},
+		{name: "env", args: "<env>", summary: "Print shell code pointing KUBECONFIG at the kubeconfig of env, for " +
+			"this shell only", setup: envCmd},
+		{name: "ca", args: "list|verify|update [env]", summary: "List, verify against the API servers, or update the " +
+			"CA certificates of clusters", setup: caCmd},
+		{name: "doctor", summary: "Diagnose common problems, with hints on how to fix them", setup: doctorCmd},
+		{name: "config", summary: "Print the kubectl-login configuration", setup: configCmd},
+		{name: "version", summary: "Print current version and exit", setup: versionCmd},
+		{name: "completion", args: "bash|zsh|fish", summary: "Print shell completion script", setup: completionCmd},
+		{name: "help", args: "[command]", summary: "Print help about a command", setup: helpCmd},
+		{name: "__complete", hidden: true, rawArgs: true, setup: completeCmd},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// run dispatches args to the command named by the first argument. Arguments starting with flags or an environment are
+// passed to login, keeping the kubectl login --force and exec plugin (--print --context=...) invocations working.
+func run(ctx context.Context, args []string) error {
+	// Environments may be added or changed by configuration. Any error reading it is reported by the commands using it.
+	if loginCfg, err := util.LoadConfig(); err == nil {
+		if err = util.ConfigureEnvironments(loginCfg.Environments); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v in %v\n", err, util.ConfigFile())
+		}
+	}
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help" || args[0] == "-help") {
+		printUsage(os.Stdout)
+		return nil
+	}
+	cmd := findCommand("login")
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch named := findCommand(args[0]); {
+		case named != nil:
+			cmd, args = named, args[1:]
+		case !isKnownEnv(args[0]):
+			return &usageError{msg: fmt.Sprintf("unknown command or environment %q", args[0])}
+		}
+	}
+	fs := cmd.flagSet()
+	runner := cmd.setup(ctx, fs)
+	if cmd.rawArgs {
+		return runner(args)
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			cmd.printHelp(os.Stdout, fs)
+			return err
+		}
+		return &usageError{command: cmd.name, msg: err.Error()}
+	}
+	return runner(positional)
+}
+
+// parseInterspersed parses flags of args, allowing flags after positional arguments like in "kubectl login prod --force"
+func parseInterspersed(fs *flag.FlagSet, args []string) (positional []string, err error) {
+	for {
+		if err = fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// choiceValue is a string flag value limited to choices, which are suggested by completion
+type choiceValue struct {
+	value   *string
+	choices []string
+}
+
+// choiceFlag defines a string flag like fs.String, whose value must be one of choices
+func choiceFlag(fs *flag.FlagSet, name, value, usage string, choices ...string) *string {
+	fs.Var(&choiceValue{value: &value, choices: choices}, name, usage)
+	return &value
+}
+
+func (c *choiceValue) String() string {
+	if c.value == nil {
+		return ""
+	}
+	return *c.value
+}
+
+func (c *choiceValue) Set(value string) error {
+	for _, choice := range c.choices {
+		if value == choice {
+			*c.value = value
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported value %q, use %v", value, strings.Join(c.choices, "|"))
+}
+
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	// Errors are reported by main, and help printed by printHelp
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// expectArgs returns a usage error unless the number of args is between min and max
+func (c *command) expectArgs(args []string, min, max int) error {
+	switch {
+	case len(args) < min:
+		return &usageError{command: c.name, msg: fmt.Sprintf("%v requires argument %v", c.name, c.args)}
+	case len(args) > max:
+		return &usageError{command: c.name, msg: fmt.Sprintf("unexpected argument(s) for %v: %v",
+			c.name, strings.Join(args[max:], " "))}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprint(w, "Usage:\n  kubectl login [command] [flags]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		if !cmd.hidden {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\n", cmd.name, cmd.summary)
+		}
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprint(w, "\nRun 'kubectl login <command> --help' for details about a command.\n")
+}
+
+func (c *command) printHelp(w io.Writer, fs *flag.FlagSet) {
+	_, _ = fmt.Fprintf(w, "%v\n\nUsage:\n  kubectl login %v", c.summary, c.name)
+	if c.args != "" {
+		_, _ = fmt.Fprintf(w, " %v", c.args)
+	}
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if !hasFlags {
+		_, _ = fmt.Fprintln(w)
+		return
+	}
+	_, _ = fmt.Fprint(w, " [flags]\n\nFlags:\n")
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		_, _ = fmt.Fprintf(w, "  %v\n        %v\n", strings.TrimSpace("--"+f.Name+" "+name),
+			strings.ReplaceAll(usage, "\n", "\n        "))
+	})
+}
+
+func loginCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	force := fs.Bool("force", false, "Force re-authentication even if a valid token is present in config")
+	execCredentialMode := fs.Bool("print", false, "Print an ExecCredential for kubectl (exec plugin mode)")
+	context := fs.String("context", "", "Authenticate for `context` rather than the current context")
+	envFlag := fs.String("env", "", "Authenticate for `env` rather than the environment of the current context")
+	all := fs.Bool("all", false, "Authenticate for all environments, one at a time")
+	verify := fs.Bool("verify", false, "Verify a new token with the API server right after login, "+
+		"like verifyLogin in the configuration")
+	skipPreflight := fs.Bool("skip-preflight", false, "Skip the probes checking that the environment is reachable "+
+		"before opening the browser")
+	browserSpec := fs.String("browser", "", "Browser to authenticate with: \"none\" to only print the URL, "+
+		"\"default\" for the system browser,\nan application name like \"Google Chrome\", "+
+		"or a command template like \"firefox -P work --new-window %s\"")
+	initEnv := fs.String("init", "", "Deprecated: use 'kubectl login init `env`' instead")
+	return func(args []string) error {
+		cmd := findCommand("login")
+		if err := cmd.expectArgs(args, 0, 1); err != nil {
+			return err
+		}
+		if *initEnv != "" {
+			return findCommand("init").setup(ctx, findCommand("init").flagSet())([]string{*initEnv})
+		}
+		if *all && *execCredentialMode {
+			return &usageError{command: cmd.name, msg: "--all can't be combined with --print"}
+		}
+		envs, err := loginTargets(args, *envFlag, *context, *all)
+		if err != nil {
+			return err
+		}
+		authenticator, err := newAuthenticator(*browserSpec)
+		if err != nil {
+			return err
+		}
+		return login(ctx, authenticator, envs, loginOptions{force: *force, execCredentialMode: *execCredentialMode,
+			context: *context, verify: *verify, skipPreflight: *skipPreflight})
+	}
+}
+
+// loginTargets returns the environments to log in to: those given as args, by --env or --context, all of them, or
+// else the environment of the current context
+func loginTargets(args []string, env, context string, all bool) ([]string, error) {
+	targets := 0
+	for _, set := range []bool{len(args) > 0, env != "", context != "", all} {
+		if set {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return nil, &usageError{command: "login", msg: "only one of [env], --env, --context and --all may be provided"}
+	}
+	var envs []string
+	switch {
+	case all:
+		envs = util.KnownEnvironments()
+	case len(args) > 0:
+		envs = args
+	case env != "":
+		envs = []string{env}
+	default:
+		env, err := currentEnv(context)
+		if err != nil {
+			return nil, err
+		}
+		envs = []string{env}
+	}
+	for _, env := range envs {
+		if !isKnownEnv(env) {
+			return nil, &usageError{command: "login", msg: fmt.Sprintf("unknown environment %q", env)}
+		}
+	}
+	return envs, nil
+}
+
+// loginOptions are the flags of login applying to each environment logged in to
+type loginOptions struct {
+	force              bool
+	execCredentialMode bool
+	// context logged in for, if given by --context
+	context       string
+	verify        bool
+	skipPreflight bool
+}
+
+// login logs in to envs one at a time. Failing to log in to one of several environments doesn't stop logins to the
+// others, but fails in the end.
+func login(ctx context.Context, authenticator *kubelogin.Authenticator, envs []string, opts loginOptions) error {
+	failed := 0
+	now := authenticator.Clock()
+	for _, env := range envs {
+		envContext := opts.context
+		if envContext == "" {
+			envContext = util.EnvToContext(env)
+		}
+		// Logins are done one at a time, and thus one browser tab at a time, reusing the SSO session of the IdP
+		token, err := authenticator.Login(ctx, env,
+			kubelogin.LoginOptions{Force: opts.force, SkipPreflight: opts.skipPreflight})
+		// As exec plugin, run by every kubectl command, only warn when logging in rather than on each command
+		if !opts.execCredentialMode || err == nil && !token.Stored {
+			warnCAExpiry(env, envContext, now, authenticator.Config.CAExpiryWarning())
+		}
+		var preflightErr *kubelogin.PreflightError
+		if errors.As(err, &preflightErr) {
+			err = fmt.Errorf("%w - use --skip-preflight to skip this check", err)
+		}
+		if err == nil && !token.Stored && (opts.verify || authenticator.Config.VerifyLogin) {
+			err = verifyLogin(ctx, authenticator, envContext, token)
+		}
+		if err == nil && !token.Stored {
+			if changes, err := authenticator.MembershipChanges(env); err == nil && changes != nil {
+				printMembershipChanges(os.Stderr, changes)
+			}
+		}
+		if err == nil && !token.Stored && !opts.execCredentialMode {
+			if err := offerTeamNamespaces(authenticator, env, envContext, token); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: failed setting team namespaces: %v\n", err)
+			}
+		}
+		switch {
+		case err != nil && len(envs) == 1:
+			return err
+		case err != nil:
+			_, _ = fmt.Fprintf(os.Stderr, "Failed authenticating for %v: %v\n", env, err)
+			failed++
+		case opts.execCredentialMode:
+			fmt.Println(fmt.Sprintf(util.ExecCredentialObject, token.Raw, token.Expiry.Format(time.RFC3339)))
+		case token.Stored:
+			fmt.Printf("Previously fetched ID token for %v still valid. "+
+				"Use kubectl login --force to force re-authentication.\n", env)
+		default:
+			fmt.Printf("Authenticated for %v. Token valid until %v.\n", env, token.Expiry)
+		}
+		if ctx.Err() != nil {
+			return kubelogin.ErrLoginCancelled
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed authenticating for %v of %v environments", failed, len(envs))
+	}
+	return nil
+}
+
+// verifyLogin checks that the API server of context accepts a newly issued token, explaining why if it doesn't. Other
+// failures to verify the token are only warned about, as the token may still be fine.
+func verifyLogin(ctx context.Context, authenticator *kubelogin.Authenticator, context string,
+	token *kubelogin.Token) error {
+	identity, err := authenticator.ReviewTokenIdentity(ctx, context, token)
+	var unauthorized *kubelogin.UnauthorizedError
+	switch {
+	case errors.As(err, &unauthorized):
+		return err
+	case err != nil:
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: could not verify token with the API server: %v\n", err)
+	case !identity.Partial:
+		_, _ = fmt.Fprintf(os.Stderr, "Token accepted by the API server of %v as user %v\n", context, identity.Username)
+	}
+	return nil
+}
+
+func isKnownEnv(env string) bool {
+	for _, known := range util.KnownEnvironments() {
+		if env == known {
+			return true
+		}
+	}
+	return false
+}
+
+func initCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	kubeconfig := fs.String("kubeconfig", "", "Merge into `file` rather than ~/.kube/config.<env>")
+	merge := fs.Bool("merge", false, "Merge into the default kubeconfig file (the first file of KUBECONFIG, "+
+		"or ~/.kube/config)")
+	dryRun := fs.Bool("dry-run", false, "Print a diff of what would change, without writing anything")
+	caFingerprint := fs.String("ca-fingerprint", "", "Expected SHA-256 `fingerprint` of the CA of a cluster "+
+		"unknown to kubectl login,\nrather than confirming the certificate presented by the cluster")
+	teamNamespaces := fs.Bool("team-namespaces", false, "Default to the namespaces of your teams, with a context "+
+		"per team if on several teams.\nLogs in if needed to find your teams.")
+	return func(args []string) error {
+		cmd := findCommand("init")
+		if err := cmd.expectArgs(args, 1, 1); err != nil {
+			return err
+		}
+		if *merge && *kubeconfig != "" {
+			return &usageError{command: cmd.name, msg: "only one of --kubeconfig and --merge may be provided"}
+		}
+		loginCfg, err := util.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed reading %v: %w", util.ConfigFile(), err)
+		}
+		opts := initOptions{target: *kubeconfig, dryRun: *dryRun, caFingerprint: *caFingerprint, config: loginCfg}
+		if *merge {
+			opts.target = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+		}
+		if *teamNamespaces {
+			if opts.teams, err = initTeams(ctx, args[0], loginCfg); err != nil {
+				return err
+			}
+		}
+		clientCfg, err := loadClientConfig("")
+		if err != nil {
+			return err
+		}
+		return initEnvironments(args[0], clientCfg, opts)
+	}
+}
+
+func whoamiCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	context := fs.String("context", "", "Print user of `context` rather than the current context")
+	server := fs.Bool("server", false, "Also print the user resolved by the API server, through a SelfSubjectReview")
+	changes := fs.Bool("changes", false, "Also print the changes of group and team membership between your last two "+
+		"logins")
+	output := choiceFlag(fs, "output", "text", "Output `format`, text or json. JSON holds username, groups, teams "+
+		"and changes only.", "text", "json")
+	return func(args []string) error {
+		cmd := findCommand("whoami")
+		if err := cmd.expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		env, err := currentEnv(*context)
+		if err != nil {
+			return err
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+		status, err := authenticator.Status(env)
+		if err != nil {
+			return err
+		}
+		if status.Token == nil {
+			return kubelogin.ErrNotLoggedIn
+		}
+		claims := status.Claims
+		var groups []string
+		if claims.Groups != nil {
+			groups = *claims.Groups
+		}
+		var membershipChanges *kubelogin.MembershipChanges
+		if *changes {
+			if membershipChanges, err = authenticator.MembershipChanges(env); err != nil {
+				return err
+			}
+		}
+		if *output == "json" {
+			return printIdentityJSON(claims.Username, groups, util.ExtractTeams(claims), membershipChanges)
+		}
+		fmt.Println(util.Whoami(claims.Username, groups, util.ExtractTeams(claims)))
+		switch {
+		case *changes && membershipChanges == nil:
+			fmt.Println("\nNo membership changes known - they are tracked from your next login on")
+		case *changes && membershipChanges.Empty():
+			fmt.Printf("\nNo membership changes between your last two logins (last at %v)\n",
+				membershipChanges.Since.Format(time.RFC3339))
+		case *changes:
+			fmt.Println()
+			printMembershipChanges(os.Stdout, membershipChanges)
+		}
+		if clientCfg, err := loadClientConfig(*context); err == nil {
+			if team, group := activeTeam(clientCfg, clientCfg.CurrentContext); team != "" {
+				fmt.Printf("\nActive team context %v: acting as team %v, with the rights of group %v only\n",
+					clientCfg.CurrentContext, team, group)
+			}
+		}
+
+		// What the API server sees may differ, given the prefixes and claims it is configured with
+		user, err := authenticator.KubernetesUser(env, status.Token)
+		switch {
+		case err != nil:
+			fmt.Printf("\n%v\n", err)
+		case user != nil:
+			fmt.Printf("\nAs seen by the Kubernetes API server:\nusername: %v\ngroups: [\n%v]\n", user.Username,
+				util.Join(user.Groups, "  ", ",\n"))
+		}
+
+		if !*server {
+			return nil
+		}
+		serverContext := *context
+		if serverContext == "" {
+			serverContext = util.EnvToContext(env)
+		}
+		identity, err := authenticator.ReviewTokenIdentity(ctx, serverContext, status.Token)
+		if err != nil {
+			return err
+		}
+		printServerIdentity(serverContext, identity)
+		return nil
+	}
+}
+
+// printMembershipChanges prints changes of team and group membership, if any
+func printMembershipChanges(w io.Writer, changes *kubelogin.MembershipChanges) {
+	if !changes.Teams.Empty() {
+		_, _ = fmt.Fprintf(w, "Your teams changed since your previous login - %v\n", formatDiff(changes.Teams))
+	}
+	if !changes.Groups.Empty() {
+		_, _ = fmt.Fprintf(w, "Your groups changed since your previous login - %v\n", formatDiff(changes.Groups))
+	}
+}
+
+func formatDiff(diff kubelogin.Diff) string {
+	var parts []string
+	if len(diff.Added) > 0 {
+		parts = append(parts, "added: "+strings.Join(diff.Added, ", "))
+	}
+	if len(diff.Removed) > 0 {
+		parts = append(parts, "removed: "+strings.Join(diff.Removed, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func printIdentityJSON(username string, groups, teams []string, changes *kubelogin.MembershipChanges) error {
+	identity := struct {
+		Username string                       `json:"username"`
+		Groups   []string                     `json:"groups"`
+		Teams    []string                     `json:"teams"`
+		Changes  *kubelogin.MembershipChanges `json:"changes,omitempty"`
+	}{username, groups, teams, changes}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(identity)
+}
+
+func printServerIdentity(context string, identity *kubelogin.ServerIdentity) {
+	if identity.Partial {
+		fmt.Printf("\nThe API server of %v accepted the token, but doesn't support SelfSubjectReview - "+
+			"the user it resolved is unknown\n", context)
+		return
+	}
+	fmt.Printf("\nAs resolved by the API server of %v:\nusername: %v\n", context, identity.Username)
+	if identity.UID != "" {
+		fmt.Printf("uid: %v\n", identity.UID)
+	}
+	fmt.Printf("groups: [\n%v]\n", util.Join(identity.Groups, "  ", ",\n"))
+	if len(identity.Extra) > 0 {
+		keys := make([]string, 0, len(identity.Extra))
+		for key := range identity.Extra {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Println("extra:")
+		for _, key := range keys {
+			fmt.Printf("  %v: %v\n", key, strings.Join(identity.Extra[key], ", "))
+		}
+	}
+}
+
+func statusCmd(_ context.Context, _ *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := findCommand("status").expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+		envs := util.KnownEnvironments()
+		sharing := map[kubelogin.CredentialKey][]string{}
+		for _, env := range envs {
+			key := authenticator.CredentialKey(env)
+			sharing[key] = append(sharing[key], env)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ENV\tCONTEXT\tSTATUS\tEXPIRES\tUSER\tSHARED WITH")
+		now := authenticator.Clock()
+		for _, env := range envs {
+			status, err := authenticator.Status(env)
+			state, expires, user := "not logged in", "", ""
+			switch {
+			case err != nil:
+				state = "invalid token"
+			case status.LoggedIn(now):
+				state, expires, user = "valid", status.Token.Expiry.Format(time.RFC3339), status.Claims.Username
+			case status.Token != nil:
+				state, expires = "expired", status.Token.Expiry.Format(time.RFC3339)
+			}
+			var sharedWith []string
+			for _, other := range sharing[authenticator.CredentialKey(env)] {
+				if other != env {
+					sharedWith = append(sharedWith, other)
+				}
+			}
+			_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n",
+				env, util.EnvToContext(env), state, expires, user, strings.Join(sharedWith, ","))
+		}
+		if err = tw.Flush(); err != nil {
+			return err
+		}
+		if clientCfg, err := loadClientConfig(""); err == nil {
+			if team, group := activeTeam(clientCfg, clientCfg.CurrentContext); team != "" {
+				fmt.Printf("\nCurrent context %v acts as team %v, with the rights of group %v only\n",
+					clientCfg.CurrentContext, team, group)
+			}
+		}
+		return nil
+	}
+}
+
+func logoutCmd(_ context.Context, fs *flag.FlagSet) func([]string) error {
+	env := fs.String("env", "", "Log out of `env` rather than the environment of the current context")
+	all := fs.Bool("all", false, "Log out of all environments")
+	return func(args []string) error {
+		if err := findCommand("logout").expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+		envs := []string{*env}
+		switch {
+		case *all:
+			envs = util.KnownEnvironments()
+		case *env == "":
+			if envs[0], err = currentEnv(""); err != nil {
+				return err
+			}
+		}
+		for _, env := range envs {
+			if err = authenticator.Logout(env); err != nil {
+				return err
+			}
+			fmt.Printf("Logged out of %v\n", env)
+		}
+		return nil
+	}
+}
+
+func tokenCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	context := fs.String("context", "", "Print token of `context` rather than the current context")
+	envFlag := fs.String("env", "", "Print token of `env` rather than the environment of the current context. "+
+		"When decoding, the signature is verified against the issuer of env rather than that claimed by the token.")
+	format := choiceFlag(fs, "format", "raw", "Print the token as is (raw), as an Authorization `header`, as a "+
+		"shell env export, or as json. Not for decode.", tokenFormats...)
+	file := fs.String("file", "", "Decode the token in `file`, or - for stdin, rather than stdin or the token of "+
+		"the current context (decode)")
+	showSignature := fs.Bool("show-signature", false, "Show the signature rather than redacting it (decode)")
+	offline := fs.Bool("offline", false, "Don't fetch the keys of the issuer to verify the signature (decode)")
+	return func(args []string) error {
+		cmd := findCommand("token")
+		if err := cmd.expectArgs(args, 0, 2); err != nil {
+			return err
+		}
+		if *envFlag != "" && *context != "" {
+			return &usageError{command: cmd.name, msg: "only one of --env and --context may be provided"}
+		}
+		if *envFlag != "" && !isKnownEnv(*envFlag) {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown environment %q", *envFlag)}
+		}
+		decode := len(args) > 0 && args[0] == "decode"
+		// Flags of only printing or only decoding tokens are refused rather than ignored by the other
+		var misplaced string
+		fs.Visit(func(f *flag.Flag) {
+			switch {
+			case f.Name == "format" && decode:
+				misplaced = "--format doesn't apply to token decode"
+			case (f.Name == "file" || f.Name == "show-signature" || f.Name == "offline") && !decode:
+				misplaced = fmt.Sprintf("--%v only applies to token decode", f.Name)
+			}
+		})
+		if misplaced != "" {
+			return &usageError{command: cmd.name, msg: misplaced}
+		}
+		if len(args) > 0 {
+			if !decode {
+				return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown token command %q, use %v", args[0],
+					cmd.args)}
+			}
+			// Like --file -, a trailing - decodes the token on stdin
+			if len(args) == 2 {
+				switch {
+				case args[1] != "-":
+					return &usageError{command: cmd.name, msg: fmt.Sprintf("unexpected argument %q, use --file to "+
+						"decode a file", args[1])}
+				case *file != "" && *file != "-":
+					return &usageError{command: cmd.name, msg: "only one of - and --file may be provided"}
+				}
+				*file = "-"
+			}
+			return decodeToken(ctx, decodeOptions{file: *file, env: *envFlag, context: *context,
+				showSignature: *showSignature, offline: *offline})
+		}
+		env := *envFlag
+		if env == "" {
+			var err error
+			if env, err = currentEnv(*context); err != nil {
+				return err
+			}
+		}
+		if !isKnownEnv(env) {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown environment %q", env)}
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+		token, err := authenticator.Token(ctx, env)
+		if err != nil {
+			return err
+		}
+		return printToken(os.Stdout, *format, token)
+	}
+}
+
+func configCmd(_ context.Context, fs *flag.FlagSet) func([]string) error {
+	path := fs.Bool("path", false, "Only print the path of the configuration file")
+	return func(args []string) error {
+		if err := findCommand("config").expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		if *path {
+			fmt.Println(util.ConfigFile())
+			return nil
+		}
+		loginCfg, err := util.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed reading %v: %w", util.ConfigFile(), err)
+		}
+		bytes, err := yaml.Marshal(loginCfg)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %v\n%s", util.ConfigFile(), bytes)
+		return nil
+	}
+}
+
+func versionCmd(_ context.Context, _ *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := findCommand("version").expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		fmt.Println(version)
+		return nil
+	}
+}
+
+func helpCmd(_ context.Context, _ *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := findCommand("help").expectArgs(args, 0, 1); err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			printUsage(os.Stdout)
+			return nil
+		}
+		cmd := findCommand(args[0])
+		if cmd == nil || cmd.hidden {
+			return &usageError{command: "help", msg: fmt.Sprintf("unknown command %q", args[0])}
+		}
+		fs := cmd.flagSet()
+		cmd.setup(context.Background(), fs)
+		cmd.printHelp(os.Stdout, fs)
+		return nil
+	}
+}
+
+// contextNames returns all contexts known from kubeconf and the contexts of known environments, sorted
+func contextNames() []string {
+	names := map[string]bool{}
+	for _, env := range util.KnownEnvironments() {
+		names[util.EnvToContext(env)] = true
+	}
+	if clientCfg, err := loadClientConfig(""); err == nil {
+		for name := range clientCfg.Contexts {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
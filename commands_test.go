@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/golang-jwt/jwt"
+)
+
+type memoryStore map[string]string
+
+func (m memoryStore) Read(key string) (string, error) {
+	return m[key], nil
+}
+
+func (m memoryStore) Write(key string, token string) error {
+	m[key] = token
+	return nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+// fakeAuthenticator has a valid token stored for every known environment but those in unreachable, logins to which
+// fail before opening a browser
+func fakeAuthenticator(t *testing.T, unreachable ...string) *kubelogin.Authenticator {
+	authenticator := kubelogin.New(nil)
+	store := memoryStore{}
+	authenticator.Store = store
+	authenticator.Probes = func(env string) []util.Probe {
+		for _, name := range unreachable {
+			if env == name {
+				return []util.Probe{{TCP: "127.0.0.1:1", Timeout: "1s"}}
+			}
+		}
+		return nil
+	}
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "bobby@bisnode.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("much-valid-signature-ffs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range util.KnownEnvironments() {
+		store[authenticator.CredentialKey(env).String()] = raw
+	}
+	for _, env := range unreachable {
+		delete(store, authenticator.CredentialKey(env).String())
+	}
+	return authenticator
+}
+
+func TestLoginTargets(t *testing.T) {
+	kubeconf := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconf, []byte("apiVersion: v1\nkind: Config\ncurrent-context: tr.k8s.qa.blue.bisnode.net\n"),
+		0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconf)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		context  string
+		all      bool
+		expected []string
+		usage    string
+	}{
+		{name: "current context", expected: []string{"qa"}},
+		{name: "positional env", args: []string{"prod"}, expected: []string{"prod"}},
+		{name: "env flag", env: "stage", expected: []string{"stage"}},
+		{name: "context flag", context: "tr.k8s.dev.blue.bisnode.net", expected: []string{"dev"}},
+		{name: "all", all: true, expected: util.KnownEnvironments()},
+		{name: "env and flag", args: []string{"prod"}, env: "dev", usage: "only one of"},
+		{name: "env and context", env: "dev", context: "tr.k8s.qa.blue.bisnode.net", usage: "only one of"},
+		{name: "all and env", args: []string{"prod"}, all: true, usage: "only one of"},
+		{name: "unknown env", args: []string{"nope"}, usage: `unknown environment "nope"`},
+	}
+	for _, test := range tests {
+		envs, err := loginTargets(test.args, test.env, test.context, test.all)
+		var usageErr *usageError
+		switch {
+		case test.usage != "" && (!errors.As(err, &usageErr) || !strings.Contains(err.Error(), test.usage)):
+			t.Errorf("%v: expected usage error %q, got %v", test.name, test.usage, err)
+		case test.usage == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		case !reflect.DeepEqual(test.expected, envs):
+			t.Errorf("%v: expected %v, got %v", test.name, test.expected, envs)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "config"))
+
+	tests := []struct {
+		name        string
+		envs        []string
+		unreachable []string
+		expected    string
+	}{
+		{name: "stored token", envs: []string{"prod"}},
+		{name: "all stored", envs: util.KnownEnvironments()},
+		{name: "single failing", envs: []string{"qa"}, unreachable: []string{"qa"}, expected: "127.0.0.1:1"},
+		{name: "continues after failure", envs: []string{"qa", "stage", "prod"}, unreachable: []string{"qa", "prod"},
+			expected: "failed authenticating for 2 of 3 environments"},
+	}
+	for _, test := range tests {
+		err := login(context.Background(), fakeAuthenticator(t, test.unreachable...), test.envs, loginOptions{})
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		case test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)):
+			t.Errorf("%v: expected error %q, got %v", test.name, test.expected, err)
+		}
+	}
+}
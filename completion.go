@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+// Completion scripts call back to the hidden __complete command with the words typed so far, so that suggestions
+// always reflect the configured environments and contexts
+var completionScripts = map[string]string{
+	"bash": `_kubectl_login() {
+    # Words are split from COMP_LINE, as COMP_WORDS splits --flag=value at the =
+    local line="${COMP_LINE:0:$COMP_POINT}" current
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    current="${words[${#words[@]}-1]}"
+    local IFS=$'\n'
+    COMPREPLY=($(kubectl-login __complete "${words[@]:1}" 2>/dev/null))
+    # Bash replaces only what follows the = of --flag=value
+    if [[ $current == -*=* && $COMP_WORDBREAKS == *=* ]]; then
+        COMPREPLY=("${COMPREPLY[@]#"${current%%=*}="}")
+    fi
+}
+complete -o default -F _kubectl_login kubectl-login
+`,
+	"zsh": `#compdef kubectl-login
+_kubectl_login() {
+    local -a completions
+    completions=("${(@f)$(kubectl-login __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a completions
+}
+compdef _kubectl_login kubectl-login
+`,
+	"fish": `function __kubectl_login_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    kubectl-login __complete $args 2>/dev/null
+end
+complete -c kubectl-login -f -a '(__kubectl_login_complete)'
+`,
+}
+
+func completionCmd(_ context.Context, _ *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		cmd := findCommand("completion")
+		if err := cmd.expectArgs(args, 1, 1); err != nil {
+			return err
+		}
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unsupported shell %q, use %v", args[0], cmd.args)}
+		}
+		fmt.Print(script)
+		return nil
+	}
+}
+
+func completeCmd(_ context.Context, _ *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		for _, suggestion := range complete(args) {
+			fmt.Println(suggestion)
+		}
+		return nil
+	}
+}
+
+// complete returns suggestions for the last of words, which is the (possibly empty) word being typed
+func complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	previous := words[:len(words)-1]
+
+	var cmd *command
+	if len(previous) == 0 {
+		if !strings.HasPrefix(current, "-") {
+			var names []string
+			for _, c := range commands {
+				if !c.hidden {
+					names = append(names, c.name)
+				}
+			}
+			// kubectl login <env> is short for kubectl login login <env>
+			return withPrefix(append(names, util.KnownEnvironments()...), current)
+		}
+		cmd = findCommand("login")
+	} else if cmd = findCommand(previous[0]); cmd == nil || strings.HasPrefix(previous[0], "-") {
+		cmd = findCommand("login")
+	}
+
+	fs := cmd.flagSet()
+	cmd.setup(context.Background(), fs)
+
+	// Flag values, either as --flag value or --flag=value
+	flagName, prefix := "", ""
+	if name := strings.TrimLeft(current, "-"); strings.HasPrefix(current, "-") && strings.Contains(name, "=") {
+		flagName = name[:strings.Index(name, "=")]
+		prefix = current[:strings.Index(current, "=")+1]
+		current = name[strings.Index(name, "=")+1:]
+	} else if len(previous) > 0 && strings.HasPrefix(previous[len(previous)-1], "-") {
+		flagName = strings.TrimLeft(previous[len(previous)-1], "-")
+	}
+	if f := fs.Lookup(flagName); f != nil && !isBoolFlag(f) {
+		candidates := flagValues(f.Name)
+		if choice, ok := f.Value.(*choiceValue); ok {
+			candidates = choice.choices
+		}
+		var values []string
+		for _, value := range withPrefix(candidates, current) {
+			values = append(values, prefix+value)
+		}
+		return values
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) {
+			names = append(names, "--"+f.Name)
+		})
+		return withPrefix(names, current)
+	}
+
+	switch cmd.name {
+	case "login", "env":
+		return withPrefix(util.KnownEnvironments(), current)
+	case "init":
+		return withPrefix(append(util.KnownEnvironments(), "all"), current)
+	case "ca":
+		if len(previous) == 1 {
+			return withPrefix([]string{"list", "verify", "update"}, current)
+		}
+		return withPrefix(util.KnownEnvironments(), current)
+	case "team":
+		if len(previous) == 1 {
+			return withPrefix([]string{"use"}, current)
+		}
+		return withPrefix(knownTeams(), current)
+	case "token":
+		if len(previous) == 1 {
+			return withPrefix([]string{"decode"}, current)
+		}
+	case "completion":
+		return withPrefix([]string{"bash", "zsh", "fish"}, current)
+	case "help":
+		return complete([]string{current})
+	}
+	return nil
+}
+
+// flagValues returns the values of flags named name in any command. Flags limited to choices are completed with
+// those instead.
+func flagValues(name string) []string {
+	switch name {
+	case "env", "init":
+		return util.KnownEnvironments()
+	case "context":
+		return contextNames()
+	case "browser":
+		return []string{browser.None, browser.Default}
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func withPrefix(candidates []string, prefix string) (matching []string) {
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matching = append(matching, candidate)
+		}
+	}
+	return matching
+}
+
+// knownTeams returns the teams found in the stored token of the current context, without logging in
+func knownTeams() []string {
+	env, err := currentEnv("")
+	if err != nil {
+		return nil
+	}
+	authenticator, err := newAuthenticator("")
+	if err != nil {
+		return nil
+	}
+	status, err := authenticator.Status(env)
+	if err != nil || status.Token == nil {
+		return nil
+	}
+	return util.ExtractTeams(status.Claims)
+}
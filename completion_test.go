@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompletesCommandsFlagsAndValues(t *testing.T) {
+	tests := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"wh"}, []string{"whoami"}},
+		{[]string{"p"}, []string{"prod"}},
+		{[]string{"login", "--force", "q"}, []string{"qa"}},
+		{[]string{"--fo"}, []string{"--force"}},
+		{[]string{"logout", "--"}, []string{"--all", "--env"}},
+		{[]string{"logout", "--env", "st"}, []string{"stage"}},
+		{[]string{"--browser=n"}, []string{"--browser=none"}},
+		{[]string{"init", "a"}, []string{"all"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"ca", "v"}, []string{"verify"}},
+		{[]string{"ca", "update", "d"}, []string{"dev"}},
+		{[]string{"--force", "--"}, []string{"--all", "--browser", "--context", "--env", "--force", "--init", "--print",
+			"--skip-preflight", "--verify"}},
+		{[]string{"--env", "pr"}, []string{"prod"}},
+		{[]string{"whoami", "--output="}, []string{"--output=text", "--output=json"}},
+		{[]string{"access", "--output", ""}, []string{"table", "json"}},
+		{[]string{"token", "--format", "h"}, []string{"header"}},
+		{[]string{"env", "--shell=f"}, []string{"--shell=fish"}},
+	}
+	for _, test := range tests {
+		if suggestions := complete(test.words); !reflect.DeepEqual(test.expected, suggestions) {
+			t.Errorf("Expected %v to complete to %v but was %v", test.words, test.expected, suggestions)
+		}
+	}
+}
+
+func TestKubectlCompletionShimDelegatesToComplete(t *testing.T) {
+	dir := t.TempDir()
+	fake := "#!/bin/sh\necho \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl-login"), []byte(fake), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// kubectl passes the words after 'kubectl login', the last one being the (possibly empty) word typed
+	shim := exec.Command("./kubectl_complete-login", "logout", "--env", "")
+	shim.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := shim.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "__complete logout --env \n" {
+		t.Errorf("Expected shim to run kubectl-login __complete with its arguments, got %q", out)
+	}
+}
+
+func TestBashCompletionCompletesFlagValuesAfterEquals(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	dir := t.TempDir()
+	fake := "#!/bin/sh\necho \"$@\" > \"$(dirname \"$0\")/args\"\necho --output=json\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl-login"), []byte(fake), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := completionScripts["bash"] + `COMP_LINE="kubectl-login whoami --output=j"; COMP_POINT=${#COMP_LINE}
+_kubectl_login; echo "${COMPREPLY[@]}"`
+	shell := exec.Command("bash", "-c", script)
+	shell.Env = append(os.Environ(), "PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := shell.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bash only replaces what follows the =
+	if string(out) != "json\n" {
+		t.Errorf("Expected value of flag to be completed, got %q", out)
+	}
+	if args, _ := os.ReadFile(filepath.Join(dir, "args")); string(args) != "__complete whoami --output=j\n" {
+		t.Errorf("Expected --flag=value to be passed as one word, got %q", args)
+	}
+}
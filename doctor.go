@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// maxClockSkew is the clock skew tolerated before doctor fails, as tokens would appear expired or not yet valid
+const maxClockSkew = 30 * time.Second
+
+// checkResult is the outcome of a single doctor check, with a hint on how to remediate a failure
+type checkResult struct {
+	name   string
+	ok     bool
+	detail string
+	hint   string
+}
+
+func pass(name, detail string) checkResult {
+	return checkResult{name: name, ok: true, detail: detail}
+}
+
+func fail(name, detail, hint string) checkResult {
+	return checkResult{name: name, detail: detail, hint: hint}
+}
+
+func doctorCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	envFlag := fs.String("env", "", "Diagnose `env` rather than the environment of the current context")
+	return func(args []string) error {
+		cmd := findCommand("doctor")
+		if err := cmd.expectArgs(args, 0, 0); err != nil {
+			return err
+		}
+		if *envFlag != "" && !isKnownEnv(*envFlag) {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown environment %q", *envFlag)}
+		}
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+
+		results := []checkResult{checkOnPath()}
+		results = append(results, checkKubeconfs(util.ReadConfigFromContext)...)
+		currentContext, result := checkCurrentContext()
+		results = append(results, result)
+		env := *envFlag
+		if env == "" && util.IsKnownContext(currentContext) {
+			env = util.ContextToEnv(currentContext)
+		}
+		if env != "" {
+			results = append(results, checkToken(authenticator, env))
+			if proxyURL := authenticator.ClusterProxyURL(env); proxyURL != "" {
+				results = append(results, checkProxy(env, proxyURL))
+			}
+			results = append(results, checkIssuer(ctx, authenticator, env)...)
+			if authenticator.Config.Env(env).Probes != nil {
+				// The default probes are much like the issuer checks, so only configured probes are of interest
+				results = append(results, checkProbes(ctx, authenticator, env)...)
+			}
+		}
+		results = append(results, checkPort(authenticator.ListenAddr))
+
+		if failed := printResults(os.Stdout, results); failed > 0 {
+			return fmt.Errorf("%v of %v checks failed", failed, len(results))
+		}
+		return nil
+	}
+}
+
+// printResults prints a report of results, and returns the number of failed checks
+func printResults(w io.Writer, results []checkResult) (failed int) {
+	for _, result := range results {
+		state := "PASS"
+		if !result.ok {
+			state = "FAIL"
+			failed++
+		}
+		_, _ = fmt.Fprintf(w, "[%v] %v: %v\n", state, result.name, result.detail)
+		if result.hint != "" {
+			_, _ = fmt.Fprintf(w, "       Hint: %v\n", result.hint)
+		}
+	}
+	return failed
+}
+
+func checkOnPath() checkResult {
+	name := "kubectl-login on PATH"
+	path, err := exec.LookPath("kubectl-login")
+	if err != nil {
+		return fail(name, err.Error(), "kubectl only finds plugins on PATH - move kubectl-login to a directory "+
+			"on your PATH, like /usr/local/bin")
+	}
+	return pass(name, path)
+}
+
+// checkKubeconfs checks the kubeconf of each environment found by readConfig, or fails if none is found
+func checkKubeconfs(readConfig func(context string) (*api.Config, error)) (results []checkResult) {
+	for _, env := range util.KnownEnvironments() {
+		ctx := util.EnvToContext(env)
+		clientCfg, err := readConfig(ctx)
+		if err != nil {
+			continue
+		}
+		name := "kubeconf for " + env
+		// The kubeconfig of the environment is read even if it doesn't define the context of the environment
+		contextConf, ok := clientCfg.Contexts[ctx]
+		if !ok {
+			results = append(results, fail(name, fmt.Sprintf("context %v not found", ctx),
+				fmt.Sprintf("run 'kubectl login init %v'", env)))
+			continue
+		}
+		authInfo, ok := clientCfg.AuthInfos[contextConf.AuthInfo]
+		if !ok {
+			results = append(results, fail(name, fmt.Sprintf("user %v of context %v not found", contextConf.AuthInfo,
+				ctx), fmt.Sprintf("run 'kubectl login init %v'", env)))
+			continue
+		}
+		if problem := execStanzaProblem(authInfo, ctx); problem != "" {
+			results = append(results, fail(name, problem, fmt.Sprintf("run 'kubectl login init %v'", env)))
+			continue
+		}
+		results = append(results, pass(name, "exec plugin configured for context "+ctx))
+	}
+	if len(results) == 0 {
+		results = append(results, fail("kubeconf", "no context of any known environment found",
+			"run 'kubectl login init all' to initialize kubeconf"))
+	}
+	return results
+}
+
+// execStanzaProblem describes what's wrong with the exec configuration of authInfo for context, if anything
+func execStanzaProblem(authInfo *api.AuthInfo, context string) string {
+	execConf := authInfo.Exec
+	if execConf == nil {
+		return "no exec plugin configured"
+	}
+	if execConf.Command != "kubectl-login" {
+		return fmt.Sprintf("exec command is %v rather than kubectl-login", execConf.Command)
+	}
+	hasPrint, hasContext := false, false
+	for _, arg := range execConf.Args {
+		hasPrint = hasPrint || arg == "--print"
+		hasContext = hasContext || arg == "--context="+context
+	}
+	if !hasPrint || !hasContext {
+		return fmt.Sprintf("exec args %v lack --print or --context=%v", strings.Join(execConf.Args, " "), context)
+	}
+	return ""
+}
+
+func checkCurrentContext() (string, checkResult) {
+	name := "current context"
+	clientCfg, err := loadClientConfig("")
+	if err != nil {
+		return "", fail(name, err.Error(), "check that the files of KUBECONFIG are valid")
+	}
+	current := clientCfg.CurrentContext
+	switch {
+	case current == "":
+		return "", fail(name, "no current-context set", "run 'kubectl config use-context <context>', "+
+			"or 'kubectl login init <env>'")
+	case !util.IsKnownContext(current):
+		return current, fail(name, fmt.Sprintf("%v is not the context of a known environment", current),
+			"switch to one of "+strings.Join(contextNames(), ", ")+", or use --context")
+	}
+	return current, pass(name, fmt.Sprintf("%v (%v)", current, util.ContextToEnv(current)))
+}
+
+// checkToken checks that the token stored for env is readable by the current user only, and unexpired
+func checkToken(a *kubelogin.Authenticator, env string) checkResult {
+	name := "token for " + env
+	status, err := a.Status(env)
+	switch {
+	case err != nil:
+		return fail(name, err.Error(), fmt.Sprintf("run 'kubectl login %v --force'", env))
+	case status.Token == nil:
+		return fail(name, "not logged in", fmt.Sprintf("run 'kubectl login %v'", env))
+	case !status.LoggedIn(a.Clock()):
+		return fail(name, "expired at "+status.Token.Expiry.Format(time.RFC3339),
+			fmt.Sprintf("run 'kubectl login %v'", env))
+	}
+	if store, ok := a.Store.(*kubelogin.FileStore); ok {
+		file := store.Path(status.Key.String())
+		if _, err = os.Stat(file); os.IsNotExist(err) {
+			// Tokens used to be stored per environment
+			file = store.Path(env)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fail(name, err.Error(), fmt.Sprintf("run 'kubectl login %v --force'", env))
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			return fail(name, fmt.Sprintf("%v is accessible by other users (%v)", file, info.Mode().Perm()),
+				fmt.Sprintf("run 'chmod 600 %v'", file))
+		}
+	}
+	if _, err = a.KubernetesUser(env, status.Token); err != nil {
+		return fail(name, err.Error(), "check the oidc rules configured in "+util.ConfigFile()+
+			" against the --oidc-* flags of the API server, or ask for the claims needed by your account")
+	}
+	return pass(name, "valid until "+status.Token.Expiry.Format(time.RFC3339))
+}
+
+// checkIssuer checks that the issuer of env resolves and is reachable, and that the local clock agrees with the Date
+// header of its response
+func checkIssuer(ctx context.Context, a *kubelogin.Authenticator, env string) []checkResult {
+	issuer := a.Issuer(env)
+	endpoint, err := url.Parse(issuer.AuthorizeEndpoint)
+	if err != nil {
+		return []checkResult{fail("issuer "+issuer.Name, err.Error(), "check the issuer configuration")}
+	}
+	host := endpoint.Hostname()
+	if _, err = net.LookupIP(host); err != nil {
+		return []checkResult{fail(host+" resolves", err.Error(),
+			"check your DNS settings, and that you're connected to the VPN if required")}
+	}
+	results := []checkResult{pass(host+" resolves", "ok")}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer.AuthorizeEndpoint, nil)
+	if err != nil {
+		return append(results, fail(host+" reachable", err.Error(), "check the issuer configuration"))
+	}
+	client, err := a.HTTPClientFor(env)
+	if err != nil {
+		return append(results, fail(host+" reachable", err.Error(), "check the http settings of "+util.ConfigFile()))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return append(results, fail(host+" reachable", err.Error(),
+			"check your network and proxy settings, and that you're connected to the VPN if required"))
+	}
+	_ = resp.Body.Close()
+	results = append(results, pass(host+" reachable", resp.Status))
+	return append(results, checkClockSkew(resp, a.Clock()))
+}
+
+// checkProbes runs the preflight probes configured for env
+func checkProbes(ctx context.Context, a *kubelogin.Authenticator, env string) (results []checkResult) {
+	for _, probe := range a.Probes(env) {
+		name := "probe " + probe.String()
+		if err := a.RunProbe(ctx, env, probe); err != nil {
+			results = append(results, fail(name, err.Error(), "check your network and VPN connection, or the probes "+
+				"configured for "+env+" in "+util.ConfigFile()))
+			continue
+		}
+		results = append(results, pass(name, "ok"))
+	}
+	return results
+}
+
+// checkClockSkew compares now with the Date header of resp
+func checkClockSkew(resp *http.Response, now time.Time) checkResult {
+	name := "clock skew"
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return fail(name, "no valid Date header in response from issuer", "")
+	}
+	skew := now.Sub(date).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return fail(name, fmt.Sprintf("local clock differs %v from issuer", skew),
+			"synchronize your clock, e.g. by enabling NTP")
+	}
+	return pass(name, fmt.Sprintf("%v", skew))
+}
+
+// checkProxy checks that the proxy through which the cluster of env is reached accepts connections
+func checkProxy(env, proxyURL string) checkResult {
+	name := "proxy for " + env
+	address, err := util.ProxyAddress(proxyURL)
+	if err != nil {
+		return fail(name, err.Error(), "fix the proxyURL of "+env+" in "+util.ConfigFile()+", or the proxy-url "+
+			"of its cluster in kubeconf")
+	}
+	conn, err := net.DialTimeout("tcp", address, 3*time.Second)
+	if err != nil {
+		return fail(name, err.Error(), "make sure the proxy (like an SSH tunnel to the bastion) is running")
+	}
+	_ = conn.Close()
+	return pass(name, proxyURL)
+}
+
+func checkPort(addr string) checkResult {
+	name := "port " + strings.TrimPrefix(addr, ":") + " free"
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fail(name, err.Error(), "another kubectl login might be waiting for a login to complete - "+
+			"finish or abort it, or find the process with 'lsof -i "+addr+"'")
+	}
+	_ = listener.Close()
+	return pass(name, "ok")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestExecStanzaProblem(t *testing.T) {
+	ctx := "tr.k8s.dev.blue.bisnode.net"
+	tests := map[string]*api.ExecConfig{
+		"":                          {Command: "kubectl-login", Args: []string{"--print", "--context=" + ctx}},
+		"no exec plugin configured": nil,
+		"exec command is kubelogin rather than kubectl-login": {Command: "kubelogin"},
+		"exec args --print lack --print or --context=" + ctx:  {Command: "kubectl-login", Args: []string{"--print"}},
+	}
+	for expected, execConf := range tests {
+		if problem := execStanzaProblem(&api.AuthInfo{Exec: execConf}, ctx); problem != expected {
+			t.Errorf("Expected %q, got %q", expected, problem)
+		}
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[time.Duration]bool{0: true, 10 * time.Second: true, -2 * time.Minute: false, 5 * time.Minute: false}
+	for skew, ok := range tests {
+		resp := &http.Response{Header: http.Header{"Date": {now.Add(-skew).Format(http.TimeFormat)}}}
+		if result := checkClockSkew(resp, now); result.ok != ok {
+			t.Errorf("Expected skew of %v to pass: %v, got %+v", skew, ok, result)
+		}
+	}
+	if checkClockSkew(&http.Response{Header: http.Header{}}, now).ok {
+		t.Error("Expected missing Date header to fail")
+	}
+}
+
+func TestCheckKubeconfsFailsOnKubeconfLackingContextOfEnvironment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.qa")
+	err := os.WriteFile(file, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: other
+  cluster:
+    server: https://other.example.com
+users:
+- name: other
+  user: {}
+contexts:
+- name: other
+  context:
+    cluster: other
+    user: other
+current-context: other
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Like util.ReadConfigFromContext, falling back to config.<env> whether the context is in it or not
+	readConfig := func(context string) (*api.Config, error) {
+		if util.ContextToEnv(context) == "qa" {
+			return clientcmd.LoadFromFile(file)
+		}
+		return nil, errors.New("not found")
+	}
+
+	results := checkKubeconfs(readConfig)
+	if len(results) != 1 || results[0].ok || results[0].hint != "run 'kubectl login init qa'" {
+		t.Errorf("Expected a failure with a hint to init qa, got %+v", results)
+	}
+}
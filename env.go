@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// shells are the shells env prints code for, with how to evaluate it
+var shells = map[string]string{
+	"bash":       `eval "$(kubectl login env %v)"`,
+	"zsh":        `eval "$(kubectl login env %v)"`,
+	"fish":       `kubectl login env %v --shell fish | source`,
+	"powershell": `kubectl login env %v --shell powershell | Invoke-Expression`,
+}
+
+func envCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	shell := choiceFlag(fs, "shell", "", "`Shell` to print code for, bash, zsh, fish or powershell. Defaults to "+
+		"$SHELL, or bash if unknown", "bash", "zsh", "fish", "powershell")
+	login := fs.Bool("login", false, "Log in to the environment first, if no valid token is stored")
+	return func(args []string) error {
+		cmd := findCommand("env")
+		if err := cmd.expectArgs(args, 1, 1); err != nil {
+			return err
+		}
+		env := args[0]
+		if !isKnownEnv(env) {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown environment %q", env)}
+		}
+		if *shell == "" {
+			*shell = defaultShell(os.Getenv("SHELL"))
+		}
+
+		file := clientcmd.RecommendedHomeFile + "." + env
+		if err := useEnvKubeconf(env, file); err != nil {
+			return err
+		}
+
+		if *login {
+			authenticator, err := newAuthenticator("")
+			if err != nil {
+				return err
+			}
+			if _, err = authenticator.Token(ctx, env); err != nil {
+				return err
+			}
+		}
+		fmt.Print(exportCode(*shell, "KUBECONFIG", file))
+		if isTerminal(os.Stdout) {
+			_, _ = fmt.Fprintf(os.Stderr, "# Run '%v' to use %v in this shell only\n",
+				fmt.Sprintf(shells[*shell], env), env)
+		}
+		return nil
+	}
+}
+
+// useEnvKubeconf checks that file is a kubeconfig of env, whose current-context is a context of env. The file is never
+// written, as env only prints code for the shell.
+func useEnvKubeconf(env, file string) error {
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		return fmt.Errorf("failed reading file %v - run 'kubectl login init %v' first: %w", file, env, err)
+	}
+	context := util.EnvToContext(env)
+	if _, ok := kubeconf.Contexts[context]; !ok {
+		return fmt.Errorf("context %v not found in %v - run 'kubectl login init %v' first", context, file, env)
+	}
+	if util.BaseContext(kubeconf.CurrentContext) != context {
+		return fmt.Errorf("current-context of %v is %q rather than %v - run 'kubectl --kubeconfig %v config "+
+			"use-context %v' first", file, kubeconf.CurrentContext, context, file, context)
+	}
+	return nil
+}
+
+// defaultShell returns the shell of the path in $SHELL, if one env prints code for, or else bash
+func defaultShell(path string) string {
+	if _, ok := shells[filepath.Base(path)]; ok {
+		return filepath.Base(path)
+	}
+	return "bash"
+}
+
+// exportCode returns code exporting the environment variable name with value in shell
+func exportCode(shell, name, value string) string {
+	switch shell {
+	case "fish":
+		quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+		return fmt.Sprintf("set -gx %v '%v';\n", name, quoted)
+	case "powershell":
+		return fmt.Sprintf("$env:%v = '%v'\n", name, strings.ReplaceAll(value, "'", "''"))
+	}
+	return fmt.Sprintf("export %v='%v'\n", name, strings.ReplaceAll(value, "'", `'\''`))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestExportCode(t *testing.T) {
+	for shell, expected := range map[string]string{
+		"bash":       "export KUBECONFIG='/home/o'\\''neil/.kube/config.dev'\n",
+		"zsh":        "export KUBECONFIG='/home/o'\\''neil/.kube/config.dev'\n",
+		"fish":       "set -gx KUBECONFIG '/home/o\\'neil/.kube/config.dev';\n",
+		"powershell": "$env:KUBECONFIG = '/home/o''neil/.kube/config.dev'\n",
+	} {
+		if code := exportCode(shell, "KUBECONFIG", "/home/o'neil/.kube/config.dev"); code != expected {
+			t.Errorf("Expected %v code %q, got %q", shell, expected, code)
+		}
+	}
+}
+
+func TestDefaultShell(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/bin/zsh":            "zsh",
+		"/usr/local/bin/fish": "fish",
+		"/bin/sh":             "bash",
+		"":                    "bash",
+	} {
+		if shell := defaultShell(path); shell != expected {
+			t.Errorf("Expected shell %v for %q, got %v", expected, path, shell)
+		}
+	}
+}
+
+func TestUseEnvKubeconfWrittenByInit(t *testing.T) {
+	dir := t.TempDir()
+	defer func(home string) { clientcmd.RecommendedHomeFile = home }(clientcmd.RecommendedHomeFile)
+	clientcmd.RecommendedHomeFile = filepath.Join(dir, "config")
+	// The kubeconfig of qa is merged with the default one, so its current-context would be that of both
+	t.Setenv("KUBECONFIG", strings.Join([]string{clientcmd.RecommendedHomeFile, clientcmd.RecommendedHomeFile + ".qa"},
+		string(filepath.ListSeparator)))
+	clientCfg := api.NewConfig()
+	clientCfg.CurrentContext = "minikube"
+	for _, env := range []string{"dev", "qa", "prod"} {
+		// Like init all
+		err := initKubeConfContext(env, clientCfg, env == "dev", initOptions{config: &util.Config{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, env := range []string{"dev", "prod"} {
+		if err := useEnvKubeconf(env, clientcmd.RecommendedHomeFile+"."+env); err != nil {
+			t.Errorf("Expected kubeconfig of %v written by init to be usable, got %v", env, err)
+		}
+	}
+	qa := clientcmd.RecommendedHomeFile + ".qa"
+	if err := useEnvKubeconf("qa", qa); err == nil || !strings.Contains(err.Error(), "config use-context") {
+		t.Errorf("Expected kubeconfig of qa among KUBECONFIG to need its current-context set, got %v", err)
+	}
+	if kubeconf, err := clientcmd.LoadFromFile(qa); err != nil || kubeconf.CurrentContext != "" {
+		t.Errorf("Expected kubeconfig of qa to be left as is, got %v (%v)", kubeconf.CurrentContext, err)
+	}
+	if err := useEnvKubeconf("dev", clientcmd.RecommendedHomeFile+".prod"); err == nil {
+		t.Error("Expected kubeconfig of another environment to be refused")
+	}
+}
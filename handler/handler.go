@@ -1,27 +1,32 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/Bisnode/kubectl-login/util"
 	"github.com/golang-jwt/jwt"
-	"k8s.io/client-go/tools/clientcmd/api"
 )
 
-// IDTokenWebhookHandler carries configuration and any other state (like the nonce) between the main initialization and
-// the subsequent fetching of the ID token passed to the server after authenticating.
+// IDTokenWebhookHandler carries state (like the nonce) between the main initialization and the subsequent fetching of
+// the ID token passed to the server after authenticating. The outcome is delivered on the (buffered) Result channel.
 type IDTokenWebhookHandler struct {
-	ClientCfg          *api.Config
-	ForceLogin         bool
-	ExecCredentialMode bool
-	Nonce              string
-	QuitChan           chan struct{}
+	Nonce  string
+	Result chan Result
 }
 
+// Result is either the raw ID token and its expiry, or the error that stopped it from being accepted
+type Result struct {
+	Token  string
+	Expiry time.Time
+	Err    error
+}
+
+// ErrNonceMismatch is returned when the nonce in the ID token differs from that in the authorization request
+var ErrNonceMismatch = errors.New("nonce in ID token not identical to that in authorization request")
+
 // StdClaimsWithNonce - since all verification is done server side by the kubernetes API, all we are really interested
 // in here is that:
 // 1) the token is not expired or else we shouldn't store it and
@@ -37,7 +42,16 @@ func badRequest(w http.ResponseWriter, message string) {
 	log.Print(fmt.Fprintf(w, message)) //nolint
 }
 
-// Extract ID token from form POST parameter, store it in kubeconf, send 200 OK response and then exit
+// deliver passes result on without blocking, as only the first result is of interest. Result should be buffered.
+func (h *IDTokenWebhookHandler) deliver(result Result) {
+	select {
+	case h.Result <- result:
+	default:
+		log.Println("Authentication already completed. Skipping.")
+	}
+}
+
+// Extract ID token from form POST parameter, pass it on to the waiting login and send 200 OK response
 func (h *IDTokenWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -76,27 +90,13 @@ func (h *IDTokenWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 
 	if claims.Nonce != h.Nonce {
-		log.Fatal("Nonce in ID token not identical to that in authorization request. Aborting.")
-	}
-
-	exp := time.Unix(claims.ExpiresAt, 0)
-	// Print the results if run as an exec credential plugin
-	if h.ExecCredentialMode {
-		fmt.Println(fmt.Sprintf(util.ExecCredentialObject, token.Raw, exp.Format(time.RFC3339)))
-	}
-
-	err = util.WriteToken(token.Raw, h.ClientCfg.CurrentContext)
-	if err != nil {
-		log.Println(err)
-	}
-
-	if !h.ExecCredentialMode {
-		_, _ = fmt.Fprintf(os.Stdout,
-			"Authenticated for context %v. Token valid until %v.\n", h.ClientCfg.CurrentContext, exp)
+		badRequest(w, "Nonce in ID token not identical to that in authorization request. Aborting.")
+		h.deliver(Result{Err: ErrNonceMismatch})
+		return
 	}
 
-	// Return control to shell at this point
-	h.QuitChan <- struct{}{}
+	// Return control to the caller at this point
+	h.deliver(Result{Token: token.Raw, Expiry: time.Unix(claims.ExpiresAt, 0)})
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(200)
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// initOptions controls where and how kubeconf is initialized
+type initOptions struct {
+	// target is the kubeconfig file to merge into, ~/.kube/config.<env> if empty
+	target string
+	// dryRun only prints a diff of what would change
+	dryRun bool
+	// caFingerprint is the expected SHA-256 fingerprint of the CA of clusters unknown to kubectl login. If empty, the
+	// user is asked to confirm the fingerprint of the certificate presented by the cluster.
+	caFingerprint string
+	// config is the kubectl-login configuration, providing e.g. the proxy of each environment
+	config *util.Config
+	// teams of the user per environment, whose namespaces the contexts of the environment should default to
+	teams map[string][]string
+}
+
+// initEnvironments initializes kubeconf for the environments of spec: "all", or a comma separated list of environment
+// names and glob patterns like "dev,qa" or "lab*". When initializing several environments, the default one (or the
+// first, if the default isn't among them) becomes current-context unless one is already set, while ~/.kube/config.<env>
+// files not among KUBECONFIG get the context of their own environment. Failing to initialize one of several
+// environments doesn't stop the others from being initialized, but fails in the end.
+func initEnvironments(spec string, clientCfg *api.Config, opts initOptions) error {
+	envs, err := resolveEnvironments(spec)
+	if err != nil {
+		return err
+	}
+	current := envs[0]
+	for _, env := range envs {
+		if e, _ := util.LookupEnvironment(env); e.Default {
+			current = env
+		}
+	}
+	failed := 0
+	for _, env := range envs {
+		err = initKubeConfContext(env, clientCfg, env == current, opts)
+		switch {
+		case err != nil && len(envs) == 1:
+			return err
+		case err != nil:
+			_, _ = fmt.Fprintf(os.Stderr, "Failed initializing %v: %v\n", env, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed initializing %v of %v environments", failed, len(envs))
+	}
+	return nil
+}
+
+// resolveEnvironments returns the known environments matching spec, in order of appearance
+func resolveEnvironments(spec string) ([]string, error) {
+	var envs []string
+	seen := map[string]bool{}
+	for _, pattern := range strings.Split(spec, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "all" {
+			pattern = "*"
+		}
+		matched := false
+		for _, env := range util.KnownEnvironments() {
+			if ok, err := path.Match(pattern, env); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			} else if ok {
+				matched = true
+				if !seen[env] {
+					seen[env] = true
+					envs = append(envs, env)
+				}
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no environment matching %q, known environments are %v", pattern,
+				strings.Join(util.KnownEnvironments(), ", "))
+		}
+	}
+	return envs, nil
+}
+
+// Setup kubeconf for the given environment. Only the cluster, auth info and context entries owned by kubectl login are
+// updated - anything else in the file, like other contexts or the namespace of a context, is kept as is.
+func initKubeConfContext(env string, clientCfg *api.Config, setCurrentCtx bool, opts initOptions) error {
+	environment, ok := util.LookupEnvironment(env)
+	if !ok {
+		return fmt.Errorf("unknown environment %q", env)
+	}
+	ctx := environment.Context
+
+	kubeconfFile := opts.target
+	if kubeconfFile == "" {
+		kubeconfFile = clientcmd.RecommendedHomeFile + "." + env
+	}
+	before, err := ioutil.ReadFile(kubeconfFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed reading file %v: %w", kubeconfFile, err)
+	}
+	kubeconf := api.NewConfig()
+	if len(before) > 0 {
+		if kubeconf, err = clientcmd.Load(before); err != nil {
+			return fmt.Errorf("failed parsing file %v: %w", kubeconfFile, err)
+		}
+	}
+
+	cluster := kubeconf.Clusters[ctx]
+	if cluster == nil {
+		cluster = api.NewCluster()
+		kubeconf.Clusters[ctx] = cluster
+	}
+	cluster.Server = environment.ServerURL()
+	// A proxy-url added by hand is kept unless configured for the environment
+	if proxyURL := opts.config.Env(env).ProxyURL; proxyURL != "" {
+		cluster.ProxyURL = proxyURL
+	}
+	var caData []byte
+	if environment.CACert == "" {
+		fmt.Printf("Unknown cluster %v, fetching its certificate to trust on first use\n", ctx)
+		caData, err = trustOnFirstUse(cluster.Server, cluster.ProxyURL, cluster.CertificateAuthorityData,
+			opts.caFingerprint)
+		if err != nil {
+			return err
+		}
+	} else {
+		if caData, err = base64.StdEncoding.DecodeString(environment.CACert); err != nil {
+			return fmt.Errorf("failed to decode CA certificate for cluster %v", ctx)
+		}
+		caData = rotatedCA(ctx, cluster, caData)
+	}
+	cluster.InsecureSkipTLSVerify = false
+	cluster.CertificateAuthority = ""
+	cluster.CertificateAuthorityData = caData
+
+	authInfo := kubeconf.AuthInfos[ctx]
+	if authInfo == nil {
+		authInfo = api.NewAuthInfo()
+		kubeconf.AuthInfos[ctx] = authInfo
+	}
+	authInfo.Exec = &api.ExecConfig{
+		Command:    "kubectl-login",
+		Args:       []string{"--print", "--context=" + ctx},
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+	}
+
+	contextConf := kubeconf.Contexts[ctx]
+	if contextConf == nil {
+		contextConf = api.NewContext()
+		kubeconf.Contexts[ctx] = contextConf
+	}
+	contextConf.Cluster = ctx
+	contextConf.AuthInfo = ctx
+	if teams := opts.teams[env]; len(teams) > 0 {
+		setTeamNamespaces(kubeconf, ctx, opts.config.TeamNamespaceFor(env), teams, false)
+	}
+
+	// The kubeconfig of a single environment is made to use that environment, like kubectl login env does, unless it's
+	// among the files of KUBECONFIG, where its current-context could become that of them all
+	ownFile := opts.target == "" && !inKubeconfigPath(kubeconfFile)
+	if kubeconf.CurrentContext == "" && (ownFile || clientCfg.CurrentContext == "" && setCurrentCtx) {
+		fmt.Printf("No current-context configured - using context %v\n", ctx)
+		kubeconf.CurrentContext = ctx
+	}
+
+	return writeKubeConf(env, kubeconfFile, before, kubeconf, opts.dryRun)
+}
+
+// inKubeconfigPath returns true if file is among the kubeconfig files loaded by kubectl, i.e. those of KUBECONFIG or
+// ~/.kube/config
+func inKubeconfigPath(file string) bool {
+	for _, loaded := range clientcmd.NewDefaultClientConfigLoadingRules().GetLoadingPrecedence() {
+		if filepath.Clean(loaded) == filepath.Clean(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeKubeConf writes kubeconf to file unless unchanged, after backing up the previous contents of the file. In dry
+// run mode a diff of what would change is printed instead.
+func writeKubeConf(env, file string, before []byte, kubeconf *api.Config, dryRun bool) error {
+	after, err := clientcmd.Write(*kubeconf)
+	if err != nil {
+		return fmt.Errorf("failed serializing %v configuration: %w", env, err)
+	}
+	// Compare against the normalized form of the previous contents, so that formatting alone isn't a change
+	normalized := before
+	if previous, err := clientcmd.Load(before); err == nil && len(before) > 0 {
+		if normalized, err = clientcmd.Write(*previous); err != nil {
+			normalized = before
+		}
+	}
+	if string(normalized) == string(after) {
+		fmt.Printf("%v configuration in %v already up to date\n", env, file)
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("--- %v\n+++ %v (%v configuration)\n%v", file, file, env,
+			util.Diff(string(normalized), string(after), 3))
+		return nil
+	}
+
+	if len(before) > 0 {
+		backup, err := backupFile(file, before)
+		if err != nil {
+			return fmt.Errorf("failed backing up %v: %w", file, err)
+		}
+		fmt.Printf("Backed up previous %v to %v\n", file, backup)
+	}
+	if err = clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		return fmt.Errorf("failed writing config to file %v", file)
+	}
+	fmt.Printf("Stored %v configuration in %v\n", env, file)
+	return nil
+}
+
+// backupFile writes contents to a new backup of file named after the current time, returning the name of the backup.
+// Backups made within the same second, like when initializing several environments into one file, get a counter each
+// rather than replacing one another.
+func backupFile(file string, contents []byte) (string, error) {
+	stamp := time.Now().Format("20060102150405")
+	for i := 0; ; i++ {
+		backup := fmt.Sprintf("%v.%v.bak", file, stamp)
+		if i > 0 {
+			backup = fmt.Sprintf("%v.%v.%v.bak", file, stamp, i)
+		}
+		f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(contents)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return backup, err
+	}
+}
+
+// trustOnFirstUse returns the PEM encoded CA to trust for the cluster at server, fetched from the server itself. The
+// fingerprint of the certificate must either match the expected fingerprint or be confirmed by the user. Should the
+// certificate differ from the one pinned at a previous init, the user is warned loudly. The server is reached through
+// proxyURL, unless empty.
+func trustOnFirstUse(server, proxyURL string, pinned []byte, fingerprint string) ([]byte, error) {
+	chain, err := util.FetchCertificateChain(server, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	anchor := util.TrustAnchor(chain)
+	if len(pinned) > 0 && util.ContainsCertificate(pinned, anchor) {
+		return pinned, nil
+	}
+
+	if len(pinned) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, `
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+@   WARNING: THE CERTIFICATE OF THE KUBERNETES API SERVER HAS CHANGED!  @
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+Someone could be intercepting your connection to %v (man-in-the-middle
+attack), or the cluster CA might just have been rotated. Verify the new fingerprint with the cluster owners.
+
+`, server)
+	}
+	if err = confirmCertificate("presented by "+server, anchor, fingerprint); err != nil {
+		return nil, err
+	}
+	return util.EncodeCertificates(anchor), nil
+}
+
+// rotatedCA returns the CA of cluster rather than known, if the certificate presented by the cluster is signed by it,
+// like after the CA has been rotated by ca update. The user is warned when replacing a CA that can't be verified.
+func rotatedCA(ctx string, cluster *api.Cluster, known []byte) []byte {
+	if len(cluster.CertificateAuthorityData) == 0 || string(cluster.CertificateAuthorityData) == string(known) {
+		return known
+	}
+	current, err := util.ParseCertificates(cluster.CertificateAuthorityData)
+	if err == nil {
+		var chain []*x509.Certificate
+		if chain, err = util.FetchCertificateChain(cluster.Server, cluster.ProxyURL); err == nil {
+			err = util.VerifyChain(chain, current)
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: replacing CA of cluster %v with the one known to kubectl login, as "+
+			"the CA in kubeconf can't be verified against the cluster: %v\n", ctx, err)
+		return known
+	}
+	fmt.Printf("Keeping CA of cluster %v in kubeconf, as the cluster presents a certificate signed by it\n", ctx)
+	return cluster.CertificateAuthorityData
+}
+
+// confirmCertificate prints the details of cert, which must then either match the expected fingerprint or be confirmed
+// by the user
+func confirmCertificate(source string, cert *x509.Certificate, fingerprint string) error {
+	_, _ = fmt.Fprintf(os.Stderr, "Certificate %v:\n  Subject:     %v\n  Issuer:      %v\n"+
+		"  Valid until: %v\n  SHA-256:     %v\n", source, cert.Subject, cert.Issuer,
+		cert.NotAfter.Format(time.RFC3339), util.Fingerprint(cert))
+
+	if fingerprint != "" {
+		if !util.FingerprintMatches(cert, fingerprint) {
+			return fmt.Errorf("certificate %v does not match fingerprint %v", source, fingerprint)
+		}
+		return nil
+	}
+	if !confirm("Trust this certificate?") {
+		return fmt.Errorf("certificate %v not trusted - verify its fingerprint and provide it with "+
+			"--ca-fingerprint", source)
+	}
+	return nil
+}
+
+// confirm asks the user a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	_, _ = fmt.Fprintf(os.Stderr, "%v [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
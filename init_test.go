@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestInitWritesProxyURLOfEnvironment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	config := &util.Config{Environments: map[string]*util.EnvConfig{"dev": {ProxyURL: "socks5://localhost:1080"}}}
+
+	err := initKubeConfContext("dev", api.NewConfig(), true, initOptions{target: file, config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxyURL := kubeconf.Clusters[util.EnvToContext("dev")].ProxyURL; proxyURL != "socks5://localhost:1080" {
+		t.Errorf("Expected proxy-url to be written, got %q", proxyURL)
+	}
+}
+
+func TestInitKeepsProxyURLAddedByHand(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	ctx := util.EnvToContext("qa")
+	kubeconf := api.NewConfig()
+	kubeconf.Clusters[ctx] = &api.Cluster{Server: "https://api." + ctx, ProxyURL: "socks5://bastion:1080"}
+	if err := clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		t.Fatal(err)
+	}
+
+	err := initKubeConfContext("qa", api.NewConfig(), true, initOptions{target: file, config: &util.Config{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kubeconf, err = clientcmd.LoadFromFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if proxyURL := kubeconf.Clusters[ctx].ProxyURL; proxyURL != "socks5://bastion:1080" {
+		t.Errorf("Expected proxy-url to be kept, got %q", proxyURL)
+	}
+	if kubeconf.AuthInfos[ctx].Exec == nil {
+		t.Error("Expected exec plugin to be configured")
+	}
+}
+
+func TestInitMergeKeepsEntriesNotOwnedByKubectlLogin(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	original := []byte(`apiVersion: v1
+kind: Config
+preferences:
+  colors: true
+clusters:
+- name: minikube
+  cluster:
+    server: https://192.168.49.2:8443
+users:
+- name: minikube
+  user:
+    token: much-secret
+contexts:
+- name: minikube
+  context:
+    cluster: minikube
+    user: minikube
+- name: tr.k8s.dev.blue.bisnode.net
+  context:
+    cluster: tr.k8s.dev.blue.bisnode.net
+    user: tr.k8s.dev.blue.bisnode.net
+    namespace: lunatics
+current-context: minikube
+`)
+	if err := os.WriteFile(file, original, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := initEnvironments("dev,qa", api.NewConfig(), initOptions{target: file, config: &util.Config{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace := kubeconf.Contexts[util.EnvToContext("dev")].Namespace; namespace != "lunatics" {
+		t.Errorf("Expected namespace of dev context to be kept, got %q", namespace)
+	}
+	if _, ok := kubeconf.Contexts["minikube"]; !ok || kubeconf.AuthInfos["minikube"].Token != "much-secret" {
+		t.Error("Expected minikube context and user to be kept")
+	}
+	if !kubeconf.Preferences.Colors || kubeconf.CurrentContext != "minikube" {
+		t.Errorf("Expected preferences and current-context to be kept, got %+v and %q", kubeconf.Preferences,
+			kubeconf.CurrentContext)
+	}
+	for _, env := range []string{"dev", "qa"} {
+		if authInfo := kubeconf.AuthInfos[util.EnvToContext(env)]; authInfo == nil || authInfo.Exec == nil {
+			t.Errorf("Expected exec plugin to be configured for %v", env)
+		}
+	}
+
+	// Each environment merged backs up the file, without replacing the backup of the original file
+	backups, err := filepath.Glob(file + ".*.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected a backup per environment, got %v", backups)
+	}
+	originalBackedUp := false
+	for _, backup := range backups {
+		contents, err := os.ReadFile(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		originalBackedUp = originalBackedUp || bytes.Equal(contents, original)
+	}
+	if !originalBackedUp {
+		t.Errorf("Expected the original file among the backups %v", backups)
+	}
+}
+
+func TestInitContinuesPastFailingEnvironment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	// lab2 has no known CA, and its certificate won't match the fingerprint even if the cluster can be reached
+	opts := initOptions{target: file, config: &util.Config{}, caFingerprint: "AB:CD"}
+
+	err := initEnvironments("lab2,qa", api.NewConfig(), opts)
+	if err == nil || err.Error() != "failed initializing 1 of 2 environments" {
+		t.Errorf("Expected lab2 to fail, got %v", err)
+	}
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kubeconf.Contexts[util.EnvToContext("qa")]; !ok {
+		t.Error("Expected qa to be initialized after lab2 failed")
+	}
+}
+
+// withServer makes the cluster of env be served at server for the duration of the test
+func withServer(t *testing.T, env, server string) {
+	e, _ := util.LookupEnvironment(env)
+	previous := e.Server
+	if err := util.ConfigureEnvironments(map[string]*util.EnvConfig{env: {Server: server}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Server = previous })
+}
+
+func TestInitKeepsRotatedCAPresentedByCluster(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	withServer(t, "qa", server.URL)
+	ctx := util.EnvToContext("qa")
+	file := filepath.Join(t.TempDir(), "config")
+	kubeconf := api.NewConfig()
+	kubeconf.Clusters[ctx] = &api.Cluster{Server: server.URL,
+		CertificateAuthorityData: util.EncodeCertificates(server.Certificate())}
+	if err := clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initKubeConfContext("qa", api.NewConfig(), true, initOptions{target: file,
+		config: &util.Config{}}); err != nil {
+		t.Fatal(err)
+	}
+	if kubeconf, err := clientcmd.LoadFromFile(file); err != nil ||
+		!util.ContainsCertificate(kubeconf.Clusters[ctx].CertificateAuthorityData, server.Certificate()) {
+		t.Errorf("Expected rotated CA presented by the cluster to be kept (%v)", err)
+	}
+
+	// A CA the cluster doesn't present, like that of another cluster, is replaced by the one known to kubectl login
+	other, err := base64.StdEncoding.DecodeString(util.ClusterCaCert(util.EnvToContext("dev")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconf.Clusters[ctx].CertificateAuthorityData = other
+	if err = clientcmd.WriteToFile(*kubeconf, file); err != nil {
+		t.Fatal(err)
+	}
+	if err = initKubeConfContext("qa", api.NewConfig(), true, initOptions{target: file,
+		config: &util.Config{}}); err != nil {
+		t.Fatal(err)
+	}
+	known, err := base64.StdEncoding.DecodeString(util.ClusterCaCert(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kubeconf, err := clientcmd.LoadFromFile(file); err != nil ||
+		!bytes.Equal(kubeconf.Clusters[ctx].CertificateAuthorityData, known) {
+		t.Errorf("Expected CA not presented by the cluster to be replaced (%v)", err)
+	}
+}
+
+// selfSignedServer returns a TLS server presenting a certificate of its own, rather than the one shared by httptest
+func selfSignedServer(t *testing.T) *httptest.Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "kubernetes"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)}, NotBefore: time.Now().Add(-time.Hour),
+		NotAfter: time.Now().Add(time.Hour), IsCA: true, BasicConstraintsValid: true,
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	server.StartTLS()
+	return server
+}
+
+// captureStderr returns what f writes to stderr
+func captureStderr(t *testing.T, f func()) string {
+	file, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(stderr *os.File) { os.Stderr = stderr }(os.Stderr)
+	os.Stderr = file
+	f()
+	contents, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestReinitWarnsLoudlyWhenCertificateOfTrustedOnFirstUseClusterChanged(t *testing.T) {
+	pinnedServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer pinnedServer.Close()
+	withServer(t, "lab2", pinnedServer.URL)
+	file := filepath.Join(t.TempDir(), "config")
+	opts := initOptions{target: file, config: &util.Config{},
+		caFingerprint: util.Fingerprint(pinnedServer.Certificate())}
+	if err := initKubeConfContext("lab2", api.NewConfig(), true, opts); err != nil {
+		t.Fatal(err)
+	}
+	// Re-init of an unchanged cluster is quiet
+	if stderr := captureStderr(t, func() {
+		if err := initKubeConfContext("lab2", api.NewConfig(), true, opts); err != nil {
+			t.Error(err)
+		}
+	}); strings.Contains(stderr, "HAS CHANGED") {
+		t.Errorf("Expected no warning for unchanged certificate, got\n%v", stderr)
+	}
+
+	changedServer := selfSignedServer(t)
+	defer changedServer.Close()
+	withServer(t, "lab2", changedServer.URL)
+	var err error
+	stderr := captureStderr(t, func() {
+		err = initKubeConfContext("lab2", api.NewConfig(), true, opts)
+	})
+	if !strings.Contains(stderr, "WARNING: THE CERTIFICATE OF THE KUBERNETES API SERVER HAS CHANGED!") {
+		t.Errorf("Expected loud warning for changed certificate, got\n%v", stderr)
+	}
+	if err == nil {
+		t.Error("Expected changed certificate not matching the fingerprint to fail")
+	}
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := kubeconf.Clusters[util.EnvToContext("lab2")].CertificateAuthorityData
+	if !util.ContainsCertificate(pinned, pinnedServer.Certificate()) {
+		t.Error("Expected previously pinned certificate to be kept")
+	}
+}
+
+func TestResolveEnvironments(t *testing.T) {
+	tests := map[string][]string{
+		"all":       {"dev", "lab", "lab2", "prod", "qa", "stage"},
+		"qa":        {"qa"},
+		"dev, qa":   {"dev", "qa"},
+		"lab*,prod": {"lab", "lab2", "prod"},
+		"lab,la*":   {"lab", "lab2"},
+		"nope":      nil,
+		"dev,x*":    nil,
+	}
+	for spec, expected := range tests {
+		envs, err := resolveEnvironments(spec)
+		if !reflect.DeepEqual(envs, expected) || (expected == nil) != (err != nil) {
+			t.Errorf("Expected %q to resolve to %v, got %v (%v)", spec, expected, envs, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package kubelogin
+
+import (
+	"context"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+// AccessCheckResources are the resources checked one by one through SelfSubjectAccessReviews when an API server can't
+// tell all rules of a namespace, like when authorization is (partly) done by a webhook
+var AccessCheckResources = []AccessRule{
+	{Resources: []string{"pods"}},
+	{Resources: []string{"pods/log"}},
+	{Resources: []string{"pods/exec"}},
+	{Resources: []string{"services"}},
+	{Resources: []string{"configmaps"}},
+	{Resources: []string{"secrets"}},
+	{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+	{APIGroups: []string{"apps"}, Resources: []string{"statefulsets"}},
+	{APIGroups: []string{"batch"}, Resources: []string{"jobs"}},
+	{APIGroups: []string{"batch"}, Resources: []string{"cronjobs"}},
+	{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}},
+}
+
+// AccessCheckVerbs are the verbs checked for each of AccessCheckResources
+var AccessCheckVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// AccessRule is a set of verbs allowed on resources or, for non-resource rules, URLs
+type AccessRule struct {
+	Verbs           []string `json:"verbs"`
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+}
+
+// NamespaceAccess is what the current user is allowed in a namespace
+type NamespaceAccess struct {
+	Namespace string       `json:"namespace"`
+	Rules     []AccessRule `json:"rules"`
+	// Incomplete is true if the API server could not tell all rules, in which case Rules are those of
+	// AccessCheckResources found allowed
+	Incomplete      bool   `json:"incomplete,omitempty"`
+	EvaluationError string `json:"evaluationError,omitempty"`
+}
+
+// ReviewAccess asks the API server of restCfg what the user of restCfg is allowed in each of namespaces, through a
+// SelfSubjectRulesReview per namespace. If a rules review is incomplete, AccessCheckResources are checked through
+// SelfSubjectAccessReviews instead.
+func ReviewAccess(ctx context.Context, restCfg *rest.Config, namespaces []string) ([]NamespaceAccess, error) {
+	if restCfg.QPS == 0 {
+		// Checking common resources takes dozens of reviews, which the client-go default of 5 QPS would slow down
+		restCfg = rest.CopyConfig(restCfg)
+		restCfg.QPS, restCfg.Burst = 50, 100
+	}
+	client, err := authorizationv1client.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	access := make([]NamespaceAccess, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		review, err := client.SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, reviewError(restCfg, "rules of namespace "+namespace, err)
+		}
+		namespaceAccess := NamespaceAccess{
+			Namespace:       namespace,
+			Incomplete:      review.Status.Incomplete,
+			EvaluationError: review.Status.EvaluationError,
+		}
+		if review.Status.Incomplete {
+			if namespaceAccess.Rules, err = checkAccess(ctx, client, namespace); err != nil {
+				return nil, reviewError(restCfg, "access to namespace "+namespace, err)
+			}
+		} else {
+			namespaceAccess.Rules = rulesOf(review.Status)
+		}
+		access = append(access, namespaceAccess)
+	}
+	return access, nil
+}
+
+// ReviewAccess is like the ReviewAccess function, authenticating to the API server of context with the token of its
+// environment, logging in if needed
+func (a *Authenticator) ReviewAccess(ctx context.Context, context string, namespaces []string) ([]NamespaceAccess,
+	error) {
+	restCfg, err := a.RESTConfig(context)
+	if err != nil {
+		return nil, err
+	}
+	return ReviewAccess(ctx, restCfg, namespaces)
+}
+
+func rulesOf(status authorizationv1.SubjectRulesReviewStatus) []AccessRule {
+	rules := make([]AccessRule, 0, len(status.ResourceRules)+len(status.NonResourceRules))
+	for _, rule := range status.ResourceRules {
+		rules = append(rules, AccessRule{Verbs: rule.Verbs, APIGroups: rule.APIGroups, Resources: rule.Resources,
+			ResourceNames: rule.ResourceNames})
+	}
+	for _, rule := range status.NonResourceRules {
+		rules = append(rules, AccessRule{Verbs: rule.Verbs, NonResourceURLs: rule.NonResourceURLs})
+	}
+	return rules
+}
+
+// checkAccess returns a rule for each of AccessCheckResources with the AccessCheckVerbs allowed in namespace
+func checkAccess(ctx context.Context, client authorizationv1client.AuthorizationV1Interface,
+	namespace string) ([]AccessRule, error) {
+	var rules []AccessRule
+	for _, resource := range AccessCheckResources {
+		group := ""
+		if len(resource.APIGroups) > 0 {
+			group = resource.APIGroups[0]
+		}
+		name, subresource, _ := strings.Cut(resource.Resources[0], "/")
+		rule := AccessRule{APIGroups: resource.APIGroups, Resources: resource.Resources}
+		for _, verb := range AccessCheckVerbs {
+			attributes := &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       group,
+				Resource:    name,
+				Subresource: subresource,
+			}
+			review, err := client.SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if review.Status.Allowed {
+				rule.Verbs = append(rule.Verbs, verb)
+			}
+		}
+		if len(rule.Verbs) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,76 @@
+package kubelogin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestReviewAccessListsRulesPerNamespace(t *testing.T) {
+	server := reviewServer(map[string]func(w http.ResponseWriter){
+		"/apis/authorization.k8s.io/v1/selfsubjectrulesreviews": respondJSON(http.StatusCreated, `{
+			"apiVersion": "authorization.k8s.io/v1",
+			"kind": "SelfSubjectRulesReview",
+			"status": {
+				"resourceRules": [{"verbs": ["get", "list"], "apiGroups": ["apps"], "resources": ["deployments"]}],
+				"nonResourceRules": [{"verbs": ["get"], "nonResourceURLs": ["/healthz"]}],
+				"incomplete": false
+			}
+		}`),
+	})
+	defer server.Close()
+
+	access, err := ReviewAccess(context.Background(), &rest.Config{Host: server.URL, BearerToken: "token"},
+		[]string{"cool-runners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NamespaceAccess{{Namespace: "cool-runners", Rules: []AccessRule{
+		{Verbs: []string{"get", "list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+	}}}
+	if !reflect.DeepEqual(expected, access) {
+		t.Errorf("Expected %+v but was %+v", expected, access)
+	}
+}
+
+func TestReviewAccessChecksCommonResourcesWhenRulesAreIncomplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/authorization.k8s.io/v1/selfsubjectrulesreviews":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"status": {"incomplete": true, "evaluationError": "webhook authorizer"}}`))
+		case "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews":
+			review := &authorizationv1.SelfSubjectAccessReview{}
+			if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+				t.Error(err)
+			}
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = attributes.Namespace == "cool-runners" && attributes.Resource == "pods" &&
+				attributes.Subresource == "log" && attributes.Verb == "get"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(review)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	access, err := ReviewAccess(context.Background(), &rest.Config{Host: server.URL, BearerToken: "token"},
+		[]string{"cool-runners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NamespaceAccess{{Namespace: "cool-runners", Incomplete: true, EvaluationError: "webhook authorizer",
+		Rules: []AccessRule{{Verbs: []string{"get"}, Resources: []string{"pods/log"}}}}}
+	if !reflect.DeepEqual(expected, access) {
+		t.Errorf("Expected %+v but was %+v", expected, access)
+	}
+}
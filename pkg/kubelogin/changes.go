@@ -0,0 +1,139 @@
+package kubelogin
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+// TokenMetadata is kept along with the stored token of a credential, and outlives the token itself
+type TokenMetadata struct {
+	// Groups of the last token issued
+	Groups []string `json:"groups"`
+	// PreviousGroups are the groups of the token issued before the last one, nil if unknown
+	PreviousGroups *[]string `json:"previousGroups,omitempty"`
+	// IssuedAt is when the last token was issued, replacing the token before it
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// MetadataStore is implemented by token stores able to keep metadata along with tokens. Membership changes are only
+// tracked with stores implementing it.
+type MetadataStore interface {
+	// ReadMetadata returns the stored metadata, or nil if none is stored
+	ReadMetadata(key string) (*TokenMetadata, error)
+	WriteMetadata(key string, metadata *TokenMetadata) error
+}
+
+// Diff is what was added and removed between two sets of names
+type Diff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Empty returns true if nothing was added or removed
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// MembershipChanges are the changes of group and team membership between the last two tokens issued for a credential
+type MembershipChanges struct {
+	Groups Diff `json:"groups"`
+	Teams  Diff `json:"teams"`
+	// Since is when the last token was issued, i.e. the time of the last login, from which on the changes apply. It's
+	// not when the token before the last one was issued.
+	Since time.Time `json:"since"`
+}
+
+// Empty returns true if membership didn't change
+func (c *MembershipChanges) Empty() bool {
+	return c.Groups.Empty() && c.Teams.Empty()
+}
+
+// MembershipChanges returns the changes of group and team membership between the last two tokens issued for the
+// credential of env, or nil if unknown - like before having logged in twice, or if the store keeps no metadata
+func (a *Authenticator) MembershipChanges(env string) (*MembershipChanges, error) {
+	store, ok := a.Store.(MetadataStore)
+	if !ok {
+		return nil, nil
+	}
+	metadata, err := store.ReadMetadata(a.CredentialKey(env).String())
+	if err != nil || metadata == nil || metadata.PreviousGroups == nil {
+		return nil, err
+	}
+	mapping := a.Config.ClaimMappingFor(a.Issuer(env).Name)
+	previousTeams, err := mapping.Teams(*metadata.PreviousGroups)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := mapping.Teams(metadata.Groups)
+	if err != nil {
+		return nil, err
+	}
+	return &MembershipChanges{
+		Groups: diff(*metadata.PreviousGroups, metadata.Groups),
+		Teams:  diff(previousTeams, teams),
+		Since:  metadata.IssuedAt,
+	}, nil
+}
+
+// rememberGroups records the groups of a newly issued token in the metadata of the credential of env, along with the
+// groups of the token issued before it. Previous is the token replaced, if any, used when no metadata is stored yet.
+func (a *Authenticator) rememberGroups(env, previous, issued string) error {
+	store, ok := a.Store.(MetadataStore)
+	if !ok {
+		return nil
+	}
+	key := a.CredentialKey(env).String()
+	metadata, err := store.ReadMetadata(key)
+	if err != nil {
+		return err
+	}
+	claims, err := util.ParseIdentityClaims(issued, a.Config)
+	if err != nil {
+		return err
+	}
+
+	updated := &TokenMetadata{Groups: []string{}, IssuedAt: a.Clock()}
+	if claims.Groups != nil {
+		updated.Groups = *claims.Groups
+	}
+	if metadata != nil {
+		updated.PreviousGroups = &metadata.Groups
+	} else if previous != "" {
+		// Tokens stored before metadata was kept still tell the groups
+		if previousClaims, err := util.ParseIdentityClaims(previous, a.Config); err == nil {
+			groups := []string{}
+			if previousClaims.Groups != nil {
+				groups = *previousClaims.Groups
+			}
+			updated.PreviousGroups = &groups
+		}
+	}
+	return store.WriteMetadata(key, updated)
+}
+
+func diff(before, after []string) Diff {
+	d := Diff{Added: []string{}, Removed: []string{}}
+	contains := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, name := range after {
+		if !contains(before, name) {
+			d.Added = append(d.Added, name)
+		}
+	}
+	for _, name := range before {
+		if !contains(after, name) {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	return d
+}
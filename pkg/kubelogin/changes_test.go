@@ -0,0 +1,73 @@
+package kubelogin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestMembershipChangesBetweenLogins(t *testing.T) {
+	a := testAuthenticator(memoryStore{}, nil)
+	a.Store = &FileStore{Dir: t.TempDir()}
+	groupsToken := func(groups ...string) string {
+		return signTestClaims(t, jwt.MapClaims{"email": "bobby@bisnode.com", "groups": groups})
+	}
+
+	if err := a.rememberGroups("dev", "", groupsToken("sec-tbac-team-vip-treatment", "sec-admins")); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := a.MembershipChanges("dev"); err != nil || changes != nil {
+		t.Errorf("Expected no changes known after first login, got %+v (%v)", changes, err)
+	}
+
+	err := a.rememberGroups("dev", "", groupsToken("sec-tbac-team-lunatics", "sec-admins", "sec-readers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := a.MembershipChanges("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &MembershipChanges{
+		Groups: Diff{Added: []string{"sec-readers", "sec-tbac-team-lunatics"},
+			Removed: []string{"sec-tbac-team-vip-treatment"}},
+		Teams: Diff{Added: []string{"team-lunatics"}, Removed: []string{"team-vip-treatment"}},
+		Since: testNow,
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("Expected %+v but was %+v", expected, changes)
+	}
+	// Environments sharing the credential share its changes
+	if qa, _ := a.MembershipChanges("qa"); !reflect.DeepEqual(changes, qa) {
+		t.Errorf("Expected changes of qa to be those of dev, got %+v", qa)
+	}
+}
+
+func TestGroupsOfTokenStoredBeforeMetadataAreUsed(t *testing.T) {
+	a := testAuthenticator(memoryStore{}, nil)
+	a.Store = &FileStore{Dir: t.TempDir()}
+	previous := signTestClaims(t, jwt.MapClaims{"groups": []string{"sec-tbac-team-lunatics"}})
+	issued := signTestClaims(t, jwt.MapClaims{"groups": []string{}})
+
+	if err := a.rememberGroups("dev", previous, issued); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := a.MembershipChanges("dev")
+	if err != nil || changes == nil {
+		t.Fatalf("Expected changes, got %v", err)
+	}
+	if !reflect.DeepEqual([]string{"team-lunatics"}, changes.Teams.Removed) || len(changes.Teams.Added) != 0 {
+		t.Errorf("Expected team-lunatics to be removed, got %+v", changes.Teams)
+	}
+}
+
+func TestStoreWithoutMetadataTracksNoChanges(t *testing.T) {
+	a := testAuthenticator(memoryStore{}, nil)
+	if err := a.rememberGroups("dev", "", signTestClaims(t, jwt.MapClaims{})); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := a.MembershipChanges("dev"); err != nil || changes != nil {
+		t.Errorf("Expected no changes, got %+v (%v)", changes, err)
+	}
+}
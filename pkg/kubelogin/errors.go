@@ -0,0 +1,82 @@
+package kubelogin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+var (
+	// ErrNotLoggedIn is returned when no token is found in storage for the environment
+	ErrNotLoggedIn = errors.New("no token found in storage - make sure to first login")
+	// ErrLoginTimeout is returned when no ID token was received within the login timeout
+	ErrLoginTimeout = errors.New("aborting login after idling for too long")
+	// ErrLoginCancelled is returned when the context was cancelled before an ID token was received
+	ErrLoginCancelled = errors.New("login cancelled")
+	// ErrNoJWKS is returned by VerifySignature when the keys of the issuer of a token can't be fetched
+	ErrNoJWKS = errors.New("no JWKS available")
+)
+
+// PreflightError is returned when a probe run before login fails, which normally means that the user is not on the
+// office network / VPN
+type PreflightError struct {
+	Probe util.Probe
+	Err   error
+}
+
+func (e *PreflightError) Error() string {
+	switch {
+	case e.Probe.DNS != "":
+		return fmt.Sprintf("could not resolve %v. Are you on the office network / VPN? (%v)", e.Probe.DNS, e.Err)
+	case e.Probe.TCP != "":
+		return fmt.Sprintf("could not connect to %v. Are you on the office network / VPN, or is a proxy required? (%v)",
+			e.Probe.TCP, e.Err)
+	case e.Probe.HTTPS != "":
+		return fmt.Sprintf("could not get %v from the identity provider (%v)", e.Probe.HTTPS, e.Err)
+	}
+	return fmt.Sprintf("invalid probe: %v", e.Err)
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// RejectedTokenError is returned for tokens that the API server would reject, according to the OIDC rules configured
+type RejectedTokenError struct {
+	Reason string
+}
+
+func (e *RejectedTokenError) Error() string {
+	return fmt.Sprintf("token would be rejected by the API server: %v", e.Reason)
+}
+
+// UnauthorizedError is returned when an API server rejects a token that is valid by its expiry, normally because the
+// issuer, audience or claims of the token don't match the OIDC configuration of the API server
+type UnauthorizedError struct {
+	Server string
+	// Reason is the explanation given by the API server, if any
+	Reason string
+}
+
+func (e *UnauthorizedError) Error() string {
+	msg := fmt.Sprintf("the API server at %v rejected the token (401 Unauthorized)", e.Server)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg + ". The token was issued, but its issuer, audience or claims don't match what the cluster expects " +
+		"- check the claims of the token, and that you are logging in to the right environment"
+}
+
+// InvalidTokenError is returned when a token can't be parsed
+type InvalidTokenError struct {
+	Err error
+}
+
+func (e *InvalidTokenError) Error() string {
+	return fmt.Sprintf("failed parsing claims from token: %v", e.Err)
+}
+
+func (e *InvalidTokenError) Unwrap() error {
+	return e.Err
+}
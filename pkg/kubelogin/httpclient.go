@@ -0,0 +1,124 @@
+package kubelogin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+// NewHTTPClient returns a client for requests to an identity provider, configured by conf
+func NewHTTPClient(conf util.HTTPConfig) (*http.Client, error) {
+	timeout := 30 * time.Second
+	if conf.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid HTTP timeout %v: %w", conf.Timeout, err)
+		}
+	}
+	retries := 2
+	if conf.Retries != nil {
+		retries = *conf.Retries
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          10,
+	}
+	if conf.Proxy != "" {
+		proxyURL, err := url.Parse(conf.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %v: %w", conf.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if conf.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		bundle, err := ioutil.ReadFile(conf.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in CA bundle %v", conf.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{
+		Transport: &retryTransport{Base: transport, Retries: retries, Backoff: 500 * time.Millisecond},
+		Timeout:   timeout,
+	}, nil
+}
+
+// HTTPClientFor returns the client used for requests to the identity provider of env, which is created on first use
+// from the configuration of env
+func (a *Authenticator) HTTPClientFor(env string) (*http.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if client, ok := a.httpClients[env]; ok {
+		return client, nil
+	}
+	client, err := a.HTTPClient(env)
+	if err != nil {
+		return nil, err
+	}
+	if a.httpClients == nil {
+		a.httpClients = map[string]*http.Client{}
+	}
+	a.httpClients[env] = client
+	return client, nil
+}
+
+// retryTransport retries requests without a body failing with network errors or 502, 503 or 504 responses, doubling
+// the backoff between each attempt
+type retryTransport struct {
+	Base    http.RoundTripper
+	Retries int
+	Backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if attempt >= t.Retries || req.Body != nil && req.Body != http.NoBody || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
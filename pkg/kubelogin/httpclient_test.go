@@ -0,0 +1,88 @@
+package kubelogin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+func TestHTTPClientRetriesUnavailableIdP(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(util.HTTPConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Transport.(*retryTransport).Backoff = 0
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("Expected 200 OK after 3 attempts, got %v after %v", resp.StatusCode, attempts)
+	}
+
+	attempts = 0
+	if resp, err = client.PostForm(server.URL, url.Values{"a": {"b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+		t.Errorf("Expected requests with a body not to be retried, got %v after %v", resp.StatusCode, attempts)
+	}
+}
+
+func TestHTTPClientTrustsCABundleAndUsesProxy(t *testing.T) {
+	idp := httptest.NewTLSServer(http.NotFoundHandler())
+	defer idp.Close()
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+	}))
+	defer proxy.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(bundle, util.EncodeCertificates(idp.Certificate()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	retries := 0
+	client, err := NewHTTPClient(util.HTTPConfig{CABundle: bundle, Retries: &retries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get(idp.URL); err != nil {
+		t.Errorf("Expected IdP certificate to be trusted through CA bundle, got %v", err)
+	}
+
+	client, err = NewHTTPClient(util.HTTPConfig{Proxy: proxy.URL, Retries: &retries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = client.Get("http://idp.example.com/")
+	if !proxied {
+		t.Error("Expected request to go through proxy")
+	}
+}
+
+func TestEnvironmentHTTPConfigOverridesGlobal(t *testing.T) {
+	retries := 5
+	config := &util.Config{
+		HTTP:         util.HTTPConfig{Proxy: "http://proxy:3128", Timeout: "5s"},
+		Environments: map[string]*util.EnvConfig{"prod": {HTTP: util.HTTPConfig{Timeout: "1m", Retries: &retries}}},
+	}
+	conf := config.HTTPFor("prod")
+	if conf.Proxy != "http://proxy:3128" || conf.Timeout != "1m" || *conf.Retries != 5 {
+		t.Errorf("Unexpected HTTP config for prod: %+v", conf)
+	}
+}
@@ -0,0 +1,137 @@
+package kubelogin
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// jwk is a JSON Web Key, of which only RSA and EC public keys are supported
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// VerifySignature verifies the signature of raw against the keys published by issuer, found through the discovery
+// document of issuer. The issuer claimed by raw must be issuer, as it can't be trusted before the signature is
+// verified. Claims like expiry are not verified. Returns an error wrapping ErrNoJWKS if the keys can't be fetched, and
+// the kid of the key verifying the signature otherwise.
+func VerifySignature(ctx context.Context, client *http.Client, issuer, raw string) (string, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := (&jwt.Parser{}).ParseUnverified(raw, claims); err != nil {
+		return "", &InvalidTokenError{Err: err}
+	}
+	if claimed, _ := claims["iss"].(string); claimed != issuer {
+		return "", fmt.Errorf("%w: token claims to be issued by %q, not %v", ErrNoJWKS, claimed, issuer)
+	}
+	keys, err := fetchJWKS(ctx, client, issuer)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoJWKS, err)
+	}
+
+	var kid string
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err = parser.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ = token.Header["kid"].(string)
+		for _, key := range keys {
+			if key.Kid == kid || kid == "" && len(keys) == 1 {
+				kid = key.Kid
+				return key.publicKey()
+			}
+		}
+		return nil, fmt.Errorf("no key %q published by %v", kid, issuer)
+	})
+	if err != nil {
+		return "", fmt.Errorf("signature verification failed: %w", err)
+	}
+	return kid, nil
+}
+
+func fetchJWKS(ctx context.Context, client *http.Client, issuer string) ([]jwk, error) {
+	discovery := struct {
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration",
+		&discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("no jwks_uri in discovery document of %v", issuer)
+	}
+	jwks := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := getJSON(ctx, client, discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+	return jwks.Keys, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v from %v", resp.Status, target)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed decoding response from %v: %w", target, err)
+	}
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %v: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %v: %w", k.Kid, err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curve, ok := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %v of key %v", k.Crv, k.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x of key %v: %w", k.Kid, err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y of key %v: %w", k.Kid, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %v of key %v", k.Kty, k.Kid)
+}
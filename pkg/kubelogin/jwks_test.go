@@ -0,0 +1,71 @@
+package kubelogin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestVerifySignatureWithKeysOfIssuer(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issuer *httptest.Server
+	issuer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = fmt.Fprintf(w, `{"issuer": %q, "jwks_uri": "%v/jwks"}`, issuer.URL, issuer.URL)
+		case "/jwks":
+			_, _ = fmt.Fprintf(w, `{"keys": [{"kid": "other", "kty": "RSA", "n": "AQAB", "e": "AQAB"}, `+
+				`{"kid": "k1", "kty": "RSA", "n": %q, "e": %q}]}`,
+				base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer issuer.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": issuer.URL, "exp": 1})
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired tokens are verified all the same
+	if kid, err := VerifySignature(context.Background(), issuer.Client(), issuer.URL, raw); err != nil || kid != "k1" {
+		t.Errorf("Expected signature to be verified by k1, got %q (%v)", kid, err)
+	}
+
+	parts := strings.Split(raw, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(
+		fmt.Sprintf(`{"iss": %q, "exp": 9999999999}`, issuer.URL))) + "." + parts[2]
+	if _, err = VerifySignature(context.Background(), issuer.Client(), issuer.URL, tampered); err == nil ||
+		errors.Is(err, ErrNoJWKS) {
+		t.Errorf("Expected tampered token to fail verification, got %v", err)
+	}
+
+	unknown := signTestClaims(t, jwt.MapClaims{"iss": issuer.URL + "/unknown"})
+	if _, err = VerifySignature(context.Background(), issuer.Client(), issuer.URL+"/unknown", unknown); !errors.Is(err,
+		ErrNoJWKS) {
+		t.Errorf("Expected no JWKS for unknown issuer, got %v", err)
+	}
+
+	// The claimed issuer isn't trusted
+	if _, err = VerifySignature(context.Background(), issuer.Client(), "https://issuer.example", raw); !errors.Is(err,
+		ErrNoJWKS) {
+		t.Errorf("Expected token of another issuer not to be verified, got %v", err)
+	}
+}
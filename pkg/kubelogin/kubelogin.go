@@ -0,0 +1,308 @@
+// Package kubelogin provides the Common Login authentication used by the kubectl-login plugin, for use in other Go
+// programs in need of the same tokens.
+package kubelogin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"github.com/Bisnode/kubectl-login/handler"
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/golang-jwt/jwt"
+)
+
+// ClientID is the OAuth client ID of kubectl-login, and thus also the audience of ID tokens issued to it
+const ClientID = "kubectl-login"
+
+// CredentialKey identifies a credential. All environments backed by the same issuer, client ID and audience share the
+// same credential, so logging in to one of them logs in to all of them.
+type CredentialKey struct {
+	Issuer   string
+	ClientID string
+	Audience string
+}
+
+// String returns the key in a form suitable as a file name
+func (k CredentialKey) String() string {
+	sanitize := func(s string) string {
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "https://"), "http://")
+		return strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+			return '_'
+		}, s)
+	}
+	return sanitize(k.Issuer) + "_" + sanitize(k.ClientID) + "_" + sanitize(k.Audience)
+}
+
+// Token is a raw ID token along with its expiry
+type Token struct {
+	Raw    string
+	Expiry time.Time
+	// Stored is true if the token was read from storage rather than issued by a login
+	Stored bool
+}
+
+// Valid returns true if the token is not yet expired at the given time
+func (t *Token) Valid(now time.Time) bool {
+	return now.Before(t.Expiry)
+}
+
+// LoginOptions controls the behavior of a login
+type LoginOptions struct {
+	// Force re-authentication even if a valid token is present in storage
+	Force bool
+	// SkipPreflight skips the probes checking that the environment is reachable before opening the browser
+	SkipPreflight bool
+}
+
+// Status describes the stored credential of an environment
+type Status struct {
+	Env string
+	Key CredentialKey
+	// Token is nil if not logged in
+	Token  *Token
+	Claims *util.IdentityClaims
+}
+
+// LoggedIn returns true if a token is stored and not expired at the given time
+func (s *Status) LoggedIn(now time.Time) bool {
+	return s.Token != nil && s.Token.Valid(now)
+}
+
+// Authenticator logs in to, and keeps tokens for, Common Login environments. Any of the hooks may be replaced after
+// calling New, e.g. for testing.
+type Authenticator struct {
+	Config *util.Config
+	// Browser opens the authorization URL. If nil, the browser configured for the environment is used.
+	Browser browser.Launcher
+	// Clock returns the current time, used to determine token expiry
+	Clock func() time.Time
+	// HTTPClient creates the client used for requests to the identity provider of an environment, see HTTPClientFor
+	HTTPClient func(env string) (*http.Client, error)
+	Store      TokenStore
+	// Issuer provides the issuer details of an environment
+	Issuer func(env string) util.Issuer
+	// Probes provides the probes run before login to an environment
+	Probes func(env string) []util.Probe
+	// ListenAddr is where the redirect endpoint is served during login
+	ListenAddr string
+	// LoginTimeout is how long to wait for the user to authenticate
+	LoginTimeout time.Duration
+	// Out receives messages meant for the user, like an authorization URL to open manually
+	Out io.Writer
+
+	mu          sync.Mutex
+	httpClients map[string]*http.Client
+}
+
+// New returns an Authenticator with default hooks, storing tokens in ~/.kube/kubectl-login
+func New(config *util.Config) *Authenticator {
+	if config == nil {
+		config = &util.Config{}
+	}
+	a := &Authenticator{
+		Config: config,
+		Clock:  time.Now,
+		Store:  &FileStore{Dir: util.ConfigDir()},
+		Issuer: func(env string) util.Issuer {
+			if e, ok := util.LookupEnvironment(env); ok {
+				return e.Issuer
+			}
+			return util.Issuer{}
+		},
+		ListenAddr:   ":16993",
+		LoginTimeout: 10 * time.Minute,
+		Out:          os.Stderr,
+	}
+	a.HTTPClient = func(env string) (*http.Client, error) {
+		return NewHTTPClient(a.Config.HTTPFor(env))
+	}
+	a.Probes = func(env string) []util.Probe {
+		if probes := a.Config.Env(env).Probes; probes != nil {
+			return probes
+		}
+		return a.DefaultProbes(env)
+	}
+	return a
+}
+
+// Token returns a valid token for env, logging in if none is stored or the stored one has expired
+func (a *Authenticator) Token(ctx context.Context, env string) (*Token, error) {
+	return a.Login(ctx, env, LoginOptions{})
+}
+
+// Login authenticates the user for env through the browser, unless a valid token is already stored and not forced
+func (a *Authenticator) Login(ctx context.Context, env string, opts LoginOptions) (*Token, error) {
+	if !opts.Force {
+		token, err := a.storedToken(env)
+		if err != nil {
+			return nil, err
+		}
+		if token != nil && token.Valid(a.Clock()) {
+			_, err = a.KubernetesUser(env, token)
+			if err == nil {
+				return token, nil
+			}
+			_, _ = fmt.Fprintf(a.Out, "Stored token not usable, logging in again: %v\n", err)
+		}
+	}
+
+	if !opts.SkipPreflight {
+		if err := a.Preflight(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+
+	issuer := a.Issuer(env)
+
+	listener, err := net.Listen("tcp", a.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed listening for redirect on %v: %w", a.ListenAddr, err)
+	}
+	nonce := util.RandomString(12)
+	results := make(chan handler.Result, 1)
+	server := &http.Server{
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+		Handler:        &handler.IDTokenWebhookHandler{Nonce: nonce, Result: results},
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		_ = server.Shutdown(shutdownCtx)
+		cancel()
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/redirect", port)
+	browser.Open(a.launcher(env), AuthorizeURL(issuer, redirectURI, nonce), a.Out)
+
+	timer := time.NewTimer(a.LoginTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		// A token the API server would reject is not stored, as it would only be found not usable by the next login
+		token := &Token{Raw: result.Token, Expiry: result.Expiry}
+		if _, err = a.KubernetesUser(env, token); err != nil {
+			return nil, err
+		}
+		previous, _ := a.readToken(env)
+		if err = a.Store.Write(a.CredentialKey(env).String(), result.Token); err != nil {
+			return nil, fmt.Errorf("failed storing token: %w", err)
+		}
+		if err = a.rememberGroups(env, previous, result.Token); err != nil {
+			_, _ = fmt.Fprintf(a.Out, "Failed remembering groups of token: %v\n", err)
+		}
+		return token, nil
+	case <-ctx.Done():
+		return nil, ErrLoginCancelled
+	case <-timer.C:
+		return nil, ErrLoginTimeout
+	}
+}
+
+// CredentialKey returns the key of the credential used for env
+func (a *Authenticator) CredentialKey(env string) CredentialKey {
+	return CredentialKey{Issuer: a.Issuer(env).Name, ClientID: ClientID, Audience: ClientID}
+}
+
+// Logout removes the stored token for env, and thereby for all environments sharing its credential
+func (a *Authenticator) Logout(env string) error {
+	key := a.CredentialKey(env)
+	if err := a.Store.Delete(key.String()); err != nil {
+		return err
+	}
+	// Tokens used to be stored per environment
+	for _, known := range util.KnownEnvironments() {
+		if known == env || a.CredentialKey(known) == key {
+			if err := a.Store.Delete(known); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns the stored credential of env. Not being logged in is not an error, but reflected in the status.
+func (a *Authenticator) Status(env string) (*Status, error) {
+	status := &Status{Env: env, Key: a.CredentialKey(env)}
+	raw, err := a.readToken(env)
+	if err != nil || raw == "" {
+		return status, err
+	}
+	claims, err := util.ParseIdentityClaims(raw, a.Config)
+	if err != nil {
+		return nil, &InvalidTokenError{Err: err}
+	}
+	status.Token = &Token{Raw: raw, Expiry: time.Unix(claims.ExpiresAt, 0), Stored: true}
+	status.Claims = claims
+	return status, nil
+}
+
+// AuthorizeURL returns the URL of the authorization request sent to issuer
+func AuthorizeURL(issuer util.Issuer, redirectURI string, nonce string) string {
+	authorizeParameters := map[string]string{
+		// Don't send ACR for now as this has caused problems on the SAML (ADFS) side. It _should_ work, but for now
+		// just redirect straight to the ADFS authenticator instead.
+		// "acr":           "urn:se:curity:authentication:html-form:adfs",
+		"redirect_uri":  redirectURI,
+		"client_id":     ClientID,
+		"response_type": "id_token",
+		"response_mode": "form_post",
+		"scope":         "openid%20email%20tbac",
+		"nonce":         nonce,
+	}
+	authorizeRequestURL := issuer.AuthorizeEndpoint + "?"
+	for k, v := range authorizeParameters {
+		authorizeRequestURL += k + "=" + v + "&"
+	}
+	return strings.TrimRight(authorizeRequestURL, "&")
+}
+
+func (a *Authenticator) launcher(env string) browser.Launcher {
+	if a.Browser != nil {
+		return a.Browser
+	}
+	return browser.FromSpec(a.Config.BrowserFor(env))
+}
+
+// readToken returns the raw token stored for the credential of env, or an empty string if there is none
+func (a *Authenticator) readToken(env string) (string, error) {
+	raw, err := a.Store.Read(a.CredentialKey(env).String())
+	if err != nil || raw != "" {
+		return raw, err
+	}
+	// Tokens used to be stored per environment
+	return a.Store.Read(env)
+}
+
+// storedToken returns the stored token for env, or nil if there is none
+func (a *Authenticator) storedToken(env string) (*Token, error) {
+	raw, err := a.readToken(env)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	// We are only really interested in the expiry claim - all verification will be done by the kubernetes API
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	claims := &jwt.StandardClaims{}
+	if _, _, err = parser.ParseUnverified(raw, claims); err != nil {
+		return nil, &InvalidTokenError{Err: err}
+	}
+	return &Token{Raw: raw, Expiry: time.Unix(claims.ExpiresAt, 0), Stored: true}, nil
+}
@@ -0,0 +1,203 @@
+package kubelogin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/golang-jwt/jwt"
+)
+
+var testNow = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// testKey is the credential key of all environments of testAuthenticator, as they share the same issuer
+const testKey = "127.0.0.1_kubectl-login_kubectl-login"
+
+type memoryStore map[string]string
+
+func (m memoryStore) Read(key string) (string, error) {
+	return m[key], nil
+}
+
+func (m memoryStore) Write(key string, token string) error {
+	m[key] = token
+	return nil
+}
+
+func (m memoryStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestTokenReturnsStoredTokenIfStillValid(t *testing.T) {
+	store := memoryStore{testKey: issueTestToken(t, "", testNow.Add(time.Hour))}
+	a := testAuthenticator(store, browser.LauncherFunc(func(string) error {
+		t.Fatal("Browser should not be opened when a valid token is stored")
+		return nil
+	}))
+
+	token, err := a.Token(context.Background(), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !token.Stored || token.Raw != store[testKey] {
+		t.Errorf("Expected stored token to be returned, got %+v", token)
+	}
+}
+
+func TestLoginStoresTokenPostedToRedirectEndpoint(t *testing.T) {
+	store := memoryStore{testKey: issueTestToken(t, "", testNow.Add(-time.Hour))}
+	var issued string
+	a := testAuthenticator(store, browser.LauncherFunc(func(authorizeURL string) error {
+		// Play the part of the user and the identity provider
+		parsed, err := url.Parse(authorizeURL)
+		if err != nil {
+			return err
+		}
+		issued = issueTestToken(t, parsed.Query().Get("nonce"), testNow.Add(time.Hour))
+		go func() {
+			_, _ = http.PostForm(parsed.Query().Get("redirect_uri"), url.Values{"id_token": {issued}})
+		}()
+		return nil
+	}))
+
+	token, err := a.Token(context.Background(), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Stored || token.Raw != issued || store[testKey] != issued {
+		t.Errorf("Expected newly issued token to be returned and stored, got %+v", token)
+	}
+}
+
+func TestLoginIsCancelledWithContext(t *testing.T) {
+	a := testAuthenticator(memoryStore{}, browser.LauncherFunc(func(string) error { return nil }))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := a.Login(ctx, "dev", LoginOptions{Force: true})
+	if !errors.Is(err, ErrLoginCancelled) {
+		t.Errorf("Expected login to be cancelled, got %v", err)
+	}
+}
+
+func TestStatusAndLogout(t *testing.T) {
+	store := memoryStore{testKey: issueTestToken(t, "", testNow.Add(time.Hour))}
+	a := testAuthenticator(store, nil)
+
+	status, err := a.Status("qa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.LoggedIn(testNow) || status.Claims.Username != "bobby@bisnode.com" {
+		t.Errorf("Expected to be logged in as bobby@bisnode.com, got %+v", status)
+	}
+
+	if err = a.Logout("qa"); err != nil {
+		t.Fatal(err)
+	}
+	status, err = a.Status("qa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LoggedIn(testNow) {
+		t.Error("Expected to be logged out")
+	}
+}
+
+func TestEnvironmentsWithSameIssuerShareToken(t *testing.T) {
+	store := memoryStore{}
+	a := testAuthenticator(store, nil)
+	a.Issuer = func(env string) util.Issuer {
+		if env == "prod" {
+			return util.Issuer{Name: "https://login.bisnode.com"}
+		}
+		return util.Issuer{Name: "https://dev-login.bisnode.com"}
+	}
+	if err := a.Store.Write(a.CredentialKey("dev").String(), issueTestToken(t, "", testNow.Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	for env, loggedIn := range map[string]bool{"dev": true, "lab": true, "prod": false} {
+		status, err := a.Status(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.LoggedIn(testNow) != loggedIn {
+			t.Errorf("Expected %v to be logged in: %v", env, loggedIn)
+		}
+	}
+}
+
+func TestLegacyPerEnvironmentTokenIsReadAndLoggedOut(t *testing.T) {
+	store := memoryStore{"stage": issueTestToken(t, "", testNow.Add(time.Hour))}
+	a := testAuthenticator(store, nil)
+
+	token, err := a.Token(context.Background(), "stage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !token.Stored {
+		t.Error("Expected token stored per environment to be used")
+	}
+	if err = a.Logout("stage"); err != nil {
+		t.Fatal(err)
+	}
+	if len(store) != 0 {
+		t.Errorf("Expected all tokens to be removed, got %v", store)
+	}
+}
+
+func TestCredentialKeyIsSafeAsFileName(t *testing.T) {
+	key := CredentialKey{Issuer: "https://login.bisnode.com/oauth/v2", ClientID: ClientID, Audience: ClientID}
+	if key.String() != "login.bisnode.com_oauth_v2_kubectl-login_kubectl-login" {
+		t.Errorf("Unexpected key %v", key)
+	}
+}
+
+func TestAuthorizeURLContainsRequestParameters(t *testing.T) {
+	authorizeURL := AuthorizeURL(util.Issuer{AuthorizeEndpoint: "https://login.example.com/authorize"},
+		"http://127.0.0.1:16993/redirect", "abc123")
+
+	for _, param := range []string{"nonce=abc123", "client_id=kubectl-login", "response_mode=form_post"} {
+		if !strings.Contains(authorizeURL, param) {
+			t.Errorf("Expected %v in authorize URL %v", param, authorizeURL)
+		}
+	}
+}
+
+func testAuthenticator(store TokenStore, launcher browser.Launcher) *Authenticator {
+	a := New(nil)
+	a.Store = store
+	a.Browser = launcher
+	a.Clock = func() time.Time { return testNow }
+	a.Issuer = func(string) util.Issuer {
+		return util.Issuer{Name: "http://127.0.0.1", AuthorizeEndpoint: "http://127.0.0.1/authorize"}
+	}
+	a.Probes = func(string) []util.Probe {
+		return []util.Probe{{DNS: "127.0.0.1"}}
+	}
+	a.ListenAddr = "127.0.0.1:0"
+	a.LoginTimeout = 5 * time.Second
+	return a
+}
+
+func issueTestToken(t *testing.T, nonce string, exp time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email":  "bobby@bisnode.com",
+		"groups": []string{"sec-tbac-team-cool-runners"},
+		"nonce":  nonce,
+		"exp":    exp.Unix(),
+	})
+	tokenEncoded, err := token.SignedString([]byte("much-valid-signature-ffs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenEncoded
+}
@@ -0,0 +1,100 @@
+package kubelogin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/golang-jwt/jwt"
+)
+
+// KubernetesUser is the user an API server sees when authenticating a request with an ID token
+type KubernetesUser struct {
+	Username string
+	Groups   []string
+}
+
+// ApplyOIDCRules checks raw the way an API server configured with rules would, and returns the user the API server
+// would see. Signatures are not verified - only the claims are checked. If no rules are configured, nil is returned.
+func ApplyOIDCRules(rules util.OIDCConfig, raw string, now time.Time) (*KubernetesUser, error) {
+	if rules.IssuerURL == "" {
+		return nil, nil
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := (&jwt.Parser{}).ParseUnverified(raw, claims); err != nil {
+		return nil, &InvalidTokenError{Err: err}
+	}
+
+	if iss, _ := claims["iss"].(string); iss != rules.IssuerURL {
+		return nil, &RejectedTokenError{Reason: fmt.Sprintf("issuer %q is not %q", iss, rules.IssuerURL)}
+	}
+	if rules.ClientID != "" && !claims.VerifyAudience(rules.ClientID, true) {
+		return nil, &RejectedTokenError{Reason: fmt.Sprintf("audience %v does not include %q", claims["aud"],
+			rules.ClientID)}
+	}
+	if !claims.VerifyExpiresAt(now.Unix(), true) {
+		return nil, &RejectedTokenError{Reason: "token has expired"}
+	}
+	keys := make([]string, 0, len(rules.RequiredClaims))
+	for key := range rules.RequiredClaims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// Like the API server, only string claims can satisfy a required claim
+		if value, ok := claims[key].(string); !ok || value != rules.RequiredClaims[key] {
+			return nil, &RejectedTokenError{Reason: fmt.Sprintf("required claim %v=%v not found (got %v)", key,
+				rules.RequiredClaims[key], claims[key])}
+		}
+	}
+
+	usernameClaim := rules.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = "sub"
+	}
+	username, ok := claims[usernameClaim].(string)
+	if !ok || username == "" {
+		return nil, &RejectedTokenError{Reason: fmt.Sprintf("username claim %q missing", usernameClaim)}
+	}
+	if usernameClaim == "email" {
+		if verified, present := claims["email_verified"]; present && verified != true {
+			return nil, &RejectedTokenError{Reason: "email is not verified"}
+		}
+	}
+	switch {
+	case rules.UsernamePrefix == "-":
+	case rules.UsernamePrefix != "":
+		username = rules.UsernamePrefix + username
+	case usernameClaim != "email":
+		username = rules.IssuerURL + "#" + username
+	}
+
+	user := &KubernetesUser{Username: username}
+	if rules.GroupsClaim != "" {
+		switch groups := claims[rules.GroupsClaim].(type) {
+		case string:
+			user.Groups = []string{rules.GroupsPrefix + groups}
+		case []interface{}:
+			for _, group := range groups {
+				name, ok := group.(string)
+				if !ok {
+					return nil, &RejectedTokenError{Reason: fmt.Sprintf("groups claim %q is not a list of strings",
+						rules.GroupsClaim)}
+				}
+				user.Groups = append(user.Groups, rules.GroupsPrefix+name)
+			}
+		case nil:
+		default:
+			return nil, &RejectedTokenError{Reason: fmt.Sprintf("groups claim %q is neither a string nor a list",
+				rules.GroupsClaim)}
+		}
+	}
+	return user, nil
+}
+
+// KubernetesUser returns the user the API server of env would see for token, or nil if no OIDC rules are configured
+// for env
+func (a *Authenticator) KubernetesUser(env string, token *Token) (*KubernetesUser, error) {
+	return ApplyOIDCRules(a.Config.OIDCFor(env), token.Raw, a.Clock())
+}
@@ -0,0 +1,126 @@
+package kubelogin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"github.com/Bisnode/kubectl-login/util"
+	"github.com/golang-jwt/jwt"
+)
+
+func signTestClaims(t *testing.T, claims jwt.MapClaims) string {
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("much-valid-signature-ffs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestApplyOIDCRules(t *testing.T) {
+	rules := util.OIDCConfig{
+		IssuerURL:      "https://login.bisnode.com",
+		ClientID:       "kubectl-login",
+		UsernameClaim:  "email",
+		UsernamePrefix: "oidc:",
+		GroupsClaim:    "groups",
+		GroupsPrefix:   "oidc:",
+		RequiredClaims: map[string]string{"aud": "kubectl-login"},
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    "https://login.bisnode.com",
+			"aud":    "kubectl-login",
+			"email":  "bobby@bisnode.com",
+			"groups": []string{"sec-tbac-team-cool-runners"},
+			"exp":    testNow.Add(time.Hour).Unix(),
+		}
+	}
+
+	user, err := ApplyOIDCRules(rules, signTestClaims(t, valid()), testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &KubernetesUser{Username: "oidc:bobby@bisnode.com", Groups: []string{"oidc:sec-tbac-team-cool-runners"}}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, user)
+	}
+
+	rejected := map[string]func(jwt.MapClaims){
+		"other issuer":              func(c jwt.MapClaims) { c["iss"] = "https://dev-login.bisnode.com" },
+		"other audience":            func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"required claim not string": func(c jwt.MapClaims) { c["aud"] = []string{"kubectl-login", "other"} },
+		"expired":                   func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Minute).Unix() },
+		"no username":               func(c jwt.MapClaims) { delete(c, "email") },
+		"unverified email":          func(c jwt.MapClaims) { c["email_verified"] = false },
+		"groups not strings":        func(c jwt.MapClaims) { c["groups"] = []int{1, 2} },
+	}
+	for name, modify := range rejected {
+		claims := valid()
+		modify(claims)
+		var rejectedErr *RejectedTokenError
+		if _, err = ApplyOIDCRules(rules, signTestClaims(t, claims), testNow); !errors.As(err, &rejectedErr) {
+			t.Errorf("Expected token with %v to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestUsernameIsPrefixedWithIssuerByDefault(t *testing.T) {
+	raw := signTestClaims(t, jwt.MapClaims{
+		"iss": "https://login.bisnode.com",
+		"sub": "bobby",
+		"exp": testNow.Add(time.Hour).Unix(),
+	})
+	user, err := ApplyOIDCRules(util.OIDCConfig{IssuerURL: "https://login.bisnode.com"}, raw, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "https://login.bisnode.com#bobby" || user.Groups != nil {
+		t.Errorf("Unexpected user %+v", user)
+	}
+	if user, err = ApplyOIDCRules(util.OIDCConfig{}, raw, testNow); user != nil || err != nil {
+		t.Errorf("Expected no user without rules, got %+v, %v", user, err)
+	}
+}
+
+func TestStoredTokenRejectedByRulesIsNotHandedOut(t *testing.T) {
+	store := memoryStore{testKey: issueTestToken(t, "", testNow.Add(time.Hour))}
+	a := testAuthenticator(store, browser.LauncherFunc(func(string) error { return nil }))
+	a.Config = &util.Config{OIDC: util.OIDCConfig{IssuerURL: "https://login.bisnode.com"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The test token has no issuer, so a login is attempted rather than returning the stored token
+	if _, err := a.Token(ctx, "dev"); !errors.Is(err, ErrLoginCancelled) {
+		t.Errorf("Expected stored token to be rejected and a login attempted, got %v", err)
+	}
+}
+
+func TestIssuedTokenRejectedByRulesIsNotStored(t *testing.T) {
+	store := memoryStore{}
+	a := testAuthenticator(store, browser.LauncherFunc(func(authorizeURL string) error {
+		parsed, err := url.Parse(authorizeURL)
+		if err != nil {
+			return err
+		}
+		issued := issueTestToken(t, parsed.Query().Get("nonce"), testNow.Add(time.Hour))
+		go func() {
+			_, _ = http.PostForm(parsed.Query().Get("redirect_uri"), url.Values{"id_token": {issued}})
+		}()
+		return nil
+	}))
+	a.Config = &util.Config{OIDC: util.OIDCConfig{IssuerURL: "https://login.bisnode.com"}}
+
+	// The test token has no issuer, so the API server would reject it
+	if _, err := a.Token(context.Background(), "dev"); err == nil {
+		t.Error("Expected issued token to be rejected")
+	}
+	if _, stored := store[testKey]; stored {
+		t.Error("Expected rejected token not to be stored, causing another login")
+	}
+}
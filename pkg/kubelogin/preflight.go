@@ -0,0 +1,109 @@
+package kubelogin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+// DefaultProbes returns the probes used for env unless configured otherwise: resolving the host of the authorize
+// endpoint, connecting to the API server and getting the discovery document of the issuer
+func (a *Authenticator) DefaultProbes(env string) []util.Probe {
+	issuer := a.Issuer(env)
+	var probes []util.Probe
+	if authorizeURL, err := url.Parse(issuer.AuthorizeEndpoint); err == nil && authorizeURL.Hostname() != "" {
+		probes = append(probes, util.Probe{DNS: authorizeURL.Hostname()})
+	}
+	// Clusters behind a proxy can't be connected to directly, so probe the proxy instead
+	if proxyAddress, err := util.ProxyAddress(a.ClusterProxyURL(env)); err == nil {
+		probes = append(probes, util.Probe{TCP: proxyAddress})
+	} else if serverURL, err := url.Parse(util.ClusterServer(util.EnvToContext(env))); err == nil &&
+		serverURL.Host != "" {
+		address := serverURL.Host
+		if serverURL.Port() == "" {
+			address = net.JoinHostPort(serverURL.Hostname(), "443")
+		}
+		probes = append(probes, util.Probe{TCP: address})
+	}
+	if issuer.Name != "" {
+		probes = append(probes, util.Probe{HTTPS: strings.TrimSuffix(issuer.Name, "/") +
+			"/.well-known/openid-configuration"})
+	}
+	return probes
+}
+
+// ClusterProxyURL returns the proxy through which the cluster of env is reached, as configured for env or in kubeconf,
+// or an empty string if none
+func (a *Authenticator) ClusterProxyURL(env string) string {
+	if proxyURL := a.Config.Env(env).ProxyURL; proxyURL != "" {
+		return proxyURL
+	}
+	if cluster := util.KubeconfCluster(util.EnvToContext(env)); cluster != nil {
+		return cluster.ProxyURL
+	}
+	return ""
+}
+
+// Preflight runs the probes of env, returning a PreflightError for the first one failing
+func (a *Authenticator) Preflight(ctx context.Context, env string) error {
+	for _, probe := range a.Probes(env) {
+		if err := a.RunProbe(ctx, env, probe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunProbe runs a single probe of env, returning a PreflightError if it fails
+func (a *Authenticator) RunProbe(ctx context.Context, env string, probe util.Probe) error {
+	timeout, err := probe.TimeoutDuration()
+	if err != nil {
+		return &PreflightError{Probe: probe, Err: fmt.Errorf("invalid timeout: %w", err)}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case probe.DNS != "":
+		_, err = net.DefaultResolver.LookupIPAddr(ctx, probe.DNS)
+	case probe.TCP != "":
+		var conn net.Conn
+		if conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", probe.TCP); err == nil {
+			_ = conn.Close()
+		}
+	case probe.HTTPS != "":
+		err = a.getOK(ctx, env, probe.HTTPS)
+	default:
+		err = errors.New("one of dns, tcp and https must be set")
+	}
+	if err != nil {
+		return &PreflightError{Probe: probe, Err: err}
+	}
+	return nil
+}
+
+func (a *Authenticator) getOK(ctx context.Context, env, target string) error {
+	client, err := a.HTTPClientFor(env)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package kubelogin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+func TestProbesFailWithSpecificErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = closed.Close()
+
+	a := testAuthenticator(memoryStore{}, nil)
+	tests := map[util.Probe]string{
+		{DNS: "localhost"}:                                        "",
+		{TCP: server.Listener.Addr().String()}:                    "",
+		{DNS: "no-such-host.invalid"}:                             "could not resolve no-such-host.invalid",
+		{TCP: closed.Addr().String()}:                             "could not connect to " + closed.Addr().String(),
+		{HTTPS: server.URL + "/.well-known/openid-configuration"}: "unexpected status 404",
+		{DNS: "localhost", Timeout: "soon"}:                       "invalid timeout",
+	}
+	for probe, expected := range tests {
+		err := a.RunProbe(context.Background(), "dev", probe)
+		switch {
+		case expected == "" && err != nil:
+			t.Errorf("Expected %v to succeed, got %v", probe, err)
+		case expected != "" && (err == nil || !strings.Contains(err.Error(), expected)):
+			t.Errorf("Expected %v to fail with %q, got %v", probe, expected, err)
+		}
+	}
+}
+
+func TestLoginFailsPreflightUnlessSkipped(t *testing.T) {
+	a := testAuthenticator(memoryStore{}, browser.LauncherFunc(func(string) error {
+		return errors.New("browser should not be opened")
+	}))
+	a.Probes = func(string) []util.Probe {
+		return []util.Probe{{DNS: "no-such-host.invalid"}}
+	}
+	_, err := a.Login(context.Background(), "dev", LoginOptions{})
+	var preflightErr *PreflightError
+	if !errors.As(err, &preflightErr) {
+		t.Errorf("Expected preflight error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = a.Login(ctx, "dev", LoginOptions{SkipPreflight: true}); !errors.Is(err, ErrLoginCancelled) {
+		t.Errorf("Expected login to proceed past preflight, got %v", err)
+	}
+}
+
+func TestConfiguredProbesReplaceDefaults(t *testing.T) {
+	config := &util.Config{Environments: map[string]*util.EnvConfig{"qa": {Probes: []util.Probe{}}}}
+	a := New(config)
+	if probes := a.Probes("qa"); len(probes) != 0 {
+		t.Errorf("Expected no probes for qa, got %v", probes)
+	}
+	probes := a.Probes("prod")
+	if len(probes) != 3 || probes[0].DNS != "login.bisnode.com" ||
+		probes[2].HTTPS != "https://login.bisnode.com/.well-known/openid-configuration" {
+		t.Errorf("Unexpected default probes for prod: %v", probes)
+	}
+}
@@ -0,0 +1,102 @@
+package kubelogin
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authenticationv1beta1 "k8s.io/api/authentication/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authenticationv1beta1client "k8s.io/client-go/kubernetes/typed/authentication/v1beta1"
+	"k8s.io/client-go/rest"
+)
+
+// ServerIdentity is the user an API server resolved for a token
+type ServerIdentity struct {
+	Username string
+	UID      string
+	Groups   []string
+	Extra    map[string][]string
+	// Partial is true if the API server accepted the token, but provides no way to tell which user it resolved
+	Partial bool
+}
+
+// ReviewIdentity asks the API server of restCfg which user it resolves for the credentials of restCfg, through the
+// SelfSubjectReview API. On clusters older than Kubernetes 1.27, where that API is missing, a TokenReview is made
+// instead, which most users lack permission for - but at least tells whether the token was accepted.
+func ReviewIdentity(ctx context.Context, restCfg *rest.Config) (*ServerIdentity, error) {
+	v1Client, err := authenticationv1client.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	review, err := v1Client.SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{},
+		metav1.CreateOptions{})
+	if err == nil {
+		return identityOf(review.Status.UserInfo), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, reviewError(restCfg, "identity", err)
+	}
+
+	v1beta1Client, err := authenticationv1beta1client.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	betaReview, err := v1beta1Client.SelfSubjectReviews().Create(ctx, &authenticationv1beta1.SelfSubjectReview{},
+		metav1.CreateOptions{})
+	if err == nil {
+		return identityOf(betaReview.Status.UserInfo), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, reviewError(restCfg, "identity", err)
+	}
+
+	tokenReview, err := v1Client.TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: restCfg.BearerToken},
+	}, metav1.CreateOptions{})
+	switch {
+	case apierrors.IsForbidden(err):
+		// Not allowed to review tokens, but the token was accepted as otherwise the response would be a 401
+		return &ServerIdentity{Partial: true}, nil
+	case err != nil:
+		return nil, reviewError(restCfg, "identity", err)
+	case !tokenReview.Status.Authenticated:
+		return nil, &UnauthorizedError{Server: restCfg.Host, Reason: tokenReview.Status.Error}
+	}
+	return identityOf(tokenReview.Status.User), nil
+}
+
+// ReviewTokenIdentity is like ReviewIdentity, authenticating to the API server of context with token alone. Unlike
+// with RESTConfig, a rejected token does not lead to another login.
+func (a *Authenticator) ReviewTokenIdentity(ctx context.Context, context string, token *Token) (*ServerIdentity,
+	error) {
+	restCfg, err := contextConfig(context)
+	if err != nil {
+		return nil, err
+	}
+	restCfg = stripAuth(restCfg)
+	restCfg.BearerToken = token.Raw
+	return ReviewIdentity(ctx, restCfg)
+}
+
+func identityOf(user authenticationv1.UserInfo) *ServerIdentity {
+	identity := &ServerIdentity{Username: user.Username, UID: user.UID, Groups: user.Groups}
+	if len(user.Extra) > 0 {
+		identity.Extra = map[string][]string{}
+		for key, values := range user.Extra {
+			identity.Extra[key] = values
+		}
+	}
+	return identity
+}
+
+// reviewError describes a failure reviewing subject at the API server of restCfg, returning an UnauthorizedError if
+// the credentials of restCfg were rejected
+func reviewError(restCfg *rest.Config, subject string, err error) error {
+	if apierrors.IsUnauthorized(err) {
+		return &UnauthorizedError{Server: restCfg.Host}
+	}
+	return fmt.Errorf("failed reviewing %v at %v: %w", subject, restCfg.Host, err)
+}
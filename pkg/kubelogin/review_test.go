@@ -0,0 +1,87 @@
+package kubelogin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+// reviewServer is an API server stand-in, responding to the given paths only
+func reviewServer(responses map[string]func(w http.ResponseWriter)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond, ok := responses[r.URL.Path]
+		if !ok || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		respond(w)
+	}))
+}
+
+func respondJSON(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestReviewIdentityThroughSelfSubjectReview(t *testing.T) {
+	server := reviewServer(map[string]func(w http.ResponseWriter){
+		"/apis/authentication.k8s.io/v1/selfsubjectreviews": respondJSON(http.StatusCreated, `{
+			"apiVersion": "authentication.k8s.io/v1",
+			"kind": "SelfSubjectReview",
+			"status": {"userInfo": {
+				"username": "oidc:bobby@bisnode.com",
+				"groups": ["oidc:sec-tbac-team-cool-runners", "system:authenticated"],
+				"extra": {"scopes": ["openid"]}
+			}}
+		}`),
+	})
+	defer server.Close()
+
+	identity, err := ReviewIdentity(context.Background(), &rest.Config{Host: server.URL, BearerToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != "oidc:bobby@bisnode.com" || len(identity.Groups) != 2 ||
+		identity.Extra["scopes"][0] != "openid" || identity.Partial {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+}
+
+func TestReviewIdentityFallsBackToTokenReviewOnOlderClusters(t *testing.T) {
+	server := reviewServer(map[string]func(w http.ResponseWriter){
+		"/apis/authentication.k8s.io/v1/tokenreviews": respondJSON(http.StatusForbidden, `{
+			"apiVersion": "v1", "kind": "Status", "status": "Failure", "reason": "Forbidden", "code": 403
+		}`),
+	})
+	defer server.Close()
+
+	identity, err := ReviewIdentity(context.Background(), &rest.Config{Host: server.URL, BearerToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !identity.Partial {
+		t.Errorf("Expected token to be confirmed accepted only, got %+v", identity)
+	}
+}
+
+func TestReviewIdentityExplainsRejectedToken(t *testing.T) {
+	server := reviewServer(map[string]func(w http.ResponseWriter){
+		"/apis/authentication.k8s.io/v1/selfsubjectreviews": respondJSON(http.StatusUnauthorized, `{
+			"apiVersion": "v1", "kind": "Status", "status": "Failure", "reason": "Unauthorized", "code": 401
+		}`),
+	})
+	defer server.Close()
+
+	_, err := ReviewIdentity(context.Background(), &rest.Config{Host: server.URL, BearerToken: "token"})
+	var unauthorized *UnauthorizedError
+	if !errors.As(err, &unauthorized) || unauthorized.Server != server.URL {
+		t.Errorf("Expected unauthorized error, got %v", err)
+	}
+}
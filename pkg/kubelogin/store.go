@@ -0,0 +1,90 @@
+package kubelogin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// TokenStore persists raw ID tokens by key, normally the string form of a CredentialKey
+type TokenStore interface {
+	// Read returns the stored token, or an empty string if no token is stored
+	Read(key string) (string, error)
+	Write(key string, token string) error
+	// Delete removes the stored token. Deleting a missing token is not an error.
+	Delete(key string) error
+}
+
+// FileStore stores tokens in Dir/${key}/token.jwt - by default ~/.kube/kubectl-login/${key}/token.jwt - and their
+// metadata in Dir/${key}/metadata.json
+type FileStore struct {
+	Dir string
+}
+
+// Path returns the path of the token file for key
+func (s *FileStore) Path(key string) string {
+	return filepath.Join(s.Dir, key, "token.jwt")
+}
+
+// Read returns token or empty string if missing
+func (s *FileStore) Read(key string) (string, error) {
+	bytes, err := ioutil.ReadFile(s.Path(key))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// Write stores token, readable only by the current user
+func (s *FileStore) Write(key string, token string) error {
+	err := os.MkdirAll(filepath.Dir(s.Path(key)), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.Path(key), []byte(token), 0600)
+}
+
+// Delete removes the token file for key
+func (s *FileStore) Delete(key string) error {
+	err := os.Remove(s.Path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ReadMetadata returns the metadata stored for key, or nil if missing
+func (s *FileStore) ReadMetadata(key string) (*TokenMetadata, error) {
+	bytes, err := ioutil.ReadFile(s.metadataPath(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	metadata := &TokenMetadata{}
+	if err = json.Unmarshal(bytes, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// WriteMetadata stores metadata for key, readable only by the current user
+func (s *FileStore) WriteMetadata(key string, metadata *TokenMetadata) error {
+	bytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.metadataPath(key)), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.metadataPath(key), bytes, 0600)
+}
+
+func (s *FileStore) metadataPath(key string) string {
+	return filepath.Join(s.Dir, key, "metadata.json")
+}
@@ -0,0 +1,149 @@
+package kubelogin
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// RoundTripper authenticates requests to the kubernetes API with the token of an environment, logging in when no
+// valid token is stored. A request rejected with 401 Unauthorized is retried once after a forced login.
+type RoundTripper struct {
+	Authenticator *Authenticator
+	Env           string
+	// Base performs the actual requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+
+	// Only one login at a time, no matter how many requests are waiting for it
+	mu sync.Mutex
+}
+
+// NewRoundTripper returns a RoundTripper wrapping base, authenticating requests for env
+func (a *Authenticator) NewRoundTripper(env string, base http.RoundTripper) *RoundTripper {
+	return &RoundTripper{Authenticator: a, Env: env, Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.token(req, LoginOptions{})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.base().RoundTrip(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// A request body can only be sent again if it can be rewound
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	retry := withBearer(req, "")
+	if req.Body != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	refreshed, err := rt.refresh(req, token)
+	if err != nil {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	retry.Header.Set("Authorization", "Bearer "+refreshed)
+	return rt.base().RoundTrip(retry)
+}
+
+func (rt *RoundTripper) token(req *http.Request, opts LoginOptions) (string, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	token, err := rt.Authenticator.Login(req.Context(), rt.Env, opts)
+	if err != nil {
+		return "", err
+	}
+	return token.Raw, nil
+}
+
+// refresh forces a new login, unless some other request already did so after rejected was handed out
+func (rt *RoundTripper) refresh(req *http.Request, rejected string) (string, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	token, err := rt.Authenticator.Login(req.Context(), rt.Env, LoginOptions{})
+	if err == nil && token.Raw != rejected {
+		return token.Raw, nil
+	}
+	token, err = rt.Authenticator.Login(req.Context(), rt.Env, LoginOptions{Force: true})
+	if err != nil {
+		return "", err
+	}
+	return token.Raw, nil
+}
+
+func (rt *RoundTripper) base() http.RoundTripper {
+	if rt.Base != nil {
+		return rt.Base
+	}
+	return http.DefaultTransport
+}
+
+// withBearer returns a shallow copy of req with token as bearer token, as a RoundTripper must not modify the request
+func withBearer(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	if token != "" {
+		clone.Header.Set("Authorization", "Bearer "+token)
+	}
+	return clone
+}
+
+// RESTConfig returns a client-go configuration for context, authenticated through this Authenticator rather than the
+// kubectl-login exec plugin. The context is resolved the same way as by util.LoadConfigFromContext.
+func (a *Authenticator) RESTConfig(context string) (*rest.Config, error) {
+	restCfg, err := contextConfig(context)
+	if err != nil {
+		return nil, err
+	}
+	return a.WrapConfig(restCfg, util.ContextToEnv(context)), nil
+}
+
+// contextConfig returns the client-go configuration of context as found in kubeconf
+func contextConfig(context string) (*rest.Config, error) {
+	kubeconf, err := util.ReadConfigFromContext(context)
+	if err != nil {
+		return nil, err
+	}
+	restCfg, err := clientcmd.NewNonInteractiveClientConfig(
+		*kubeconf, context, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed creating client configuration for context %v: %w", context, err)
+	}
+	return restCfg, nil
+}
+
+// stripAuth returns a copy of restCfg without any means of authentication, like an exec plugin
+func stripAuth(restCfg *rest.Config) *rest.Config {
+	stripped := rest.CopyConfig(restCfg)
+	stripped.ExecProvider = nil
+	stripped.AuthProvider = nil
+	stripped.BearerToken = ""
+	stripped.BearerTokenFile = ""
+	stripped.Username = ""
+	stripped.Password = ""
+	return stripped
+}
+
+// WrapConfig returns a copy of restCfg authenticating with the token of env. Any other means of authentication in
+// restCfg, like an exec plugin, is removed.
+func (a *Authenticator) WrapConfig(restCfg *rest.Config, env string) *rest.Config {
+	wrapped := stripAuth(restCfg)
+	previous := restCfg.WrapTransport
+	wrapped.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if previous != nil {
+			rt = previous(rt)
+		}
+		return a.NewRoundTripper(env, rt)
+	}
+	return wrapped
+}
@@ -0,0 +1,104 @@
+package kubelogin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/browser"
+	"k8s.io/client-go/rest"
+)
+
+// apiServer is a kubernetes API server stand-in accepting only the given bearer tokens
+func apiServer(accepted map[string]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !accepted[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// loginLauncher plays the part of the user and identity provider, issuing a token accepted by the API server
+func loginLauncher(t *testing.T, accepted map[string]bool, logins *int) browser.Launcher {
+	return browser.LauncherFunc(func(authorizeURL string) error {
+		parsed, err := url.Parse(authorizeURL)
+		if err != nil {
+			return err
+		}
+		*logins++
+		issued := issueTestToken(t, parsed.Query().Get("nonce"), testNow.Add(time.Hour))
+		accepted[issued] = true
+		go func() {
+			_, _ = http.PostForm(parsed.Query().Get("redirect_uri"), url.Values{"id_token": {issued}})
+		}()
+		return nil
+	})
+}
+
+func TestRoundTripperUsesStoredToken(t *testing.T) {
+	stored := issueTestToken(t, "", testNow.Add(time.Hour))
+	accepted := map[string]bool{stored: true}
+	server := apiServer(accepted)
+	defer server.Close()
+
+	logins := 0
+	a := testAuthenticator(memoryStore{testKey: stored}, loginLauncher(t, accepted, &logins))
+	client := &http.Client{Transport: a.NewRoundTripper("dev", nil)}
+
+	resp, err := client.Get(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || logins != 0 {
+		t.Errorf("Expected 200 OK without login, got %v after %v logins", resp.StatusCode, logins)
+	}
+}
+
+func TestRoundTripperRetriesOnceAfterForcedLoginOn401(t *testing.T) {
+	accepted := map[string]bool{}
+	server := apiServer(accepted)
+	defer server.Close()
+
+	logins := 0
+	// Stored token is valid by expiry, but rejected by the server
+	store := memoryStore{testKey: issueTestToken(t, "revoked", testNow.Add(time.Hour))}
+	a := testAuthenticator(store, loginLauncher(t, accepted, &logins))
+	transport, err := rest.TransportFor(a.WrapConfig(&rest.Config{Host: server.URL}, "dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || logins != 1 {
+		t.Errorf("Expected 200 OK after a single login, got %v after %v logins", resp.StatusCode, logins)
+	}
+	if !accepted[store[testKey]] {
+		t.Error("Expected token from forced login to be stored")
+	}
+}
+
+func TestRoundTripperGivesUpAfterOneRetry(t *testing.T) {
+	server := apiServer(map[string]bool{})
+	defer server.Close()
+
+	logins := 0
+	// Tokens issued are never accepted by this server
+	a := testAuthenticator(memoryStore{}, loginLauncher(t, map[string]bool{}, &logins))
+	client := &http.Client{Transport: a.NewRoundTripper("dev", nil)}
+
+	resp, err := client.Get(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || logins != 2 {
+		t.Errorf("Expected 401 after initial and forced login, got %v after %v logins", resp.StatusCode, logins)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// teamNamespaces returns the namespaces of teams given pattern, see util.TeamNamespace
+func teamNamespaces(pattern string, teams []string) []string {
+	namespaces := make([]string, 0, len(teams))
+	for _, team := range teams {
+		namespaces = append(namespaces, util.TeamNamespace(pattern, team))
+	}
+	return namespaces
+}
+
+// tokenTeams returns the teams found in the claims of token
+func tokenTeams(authenticator *kubelogin.Authenticator, token *kubelogin.Token) ([]string, error) {
+	claims, err := util.ParseIdentityClaims(token.Raw, authenticator.Config)
+	if err != nil {
+		return nil, &kubelogin.InvalidTokenError{Err: err}
+	}
+	return util.ExtractTeams(claims), nil
+}
+
+// teamContext returns the name of the context of team, derived from context
+func teamContext(context, team string) string {
+	return context + "/" + team
+}
+
+// impersonationContext returns the name of the context acting with the rights of team only, derived from context. It
+// differs from the team context of context, which acts with the rights of all teams of the user.
+func impersonationContext(context, team string) string {
+	return teamContext(context, team) + "@impersonate"
+}
+
+// setTeamNamespaces makes context in kubeconf default to the namespace of the only team in teams. For members of
+// several teams, a context per team is added instead, sharing the cluster and user of context. With keep, namespaces
+// already set (to anything but default) are left as they are. Returns the contexts changed, sorted.
+func setTeamNamespaces(kubeconf *api.Config, context, pattern string, teams []string, keep bool) []string {
+	base, ok := kubeconf.Contexts[context]
+	if !ok || len(teams) == 0 {
+		return nil
+	}
+	targets := map[string]string{}
+	if len(teams) == 1 {
+		targets[context] = util.TeamNamespace(pattern, teams[0])
+	} else {
+		for _, team := range teams {
+			targets[teamContext(context, team)] = util.TeamNamespace(pattern, team)
+		}
+	}
+
+	var changed []string
+	for name, namespace := range targets {
+		contextConf, exists := kubeconf.Contexts[name]
+		if !exists {
+			contextConf = api.NewContext()
+			contextConf.Cluster, contextConf.AuthInfo = base.Cluster, base.AuthInfo
+			kubeconf.Contexts[name] = contextConf
+		}
+		if contextConf.Namespace == namespace ||
+			keep && exists && contextConf.Namespace != "" && contextConf.Namespace != "default" {
+			continue
+		}
+		contextConf.Namespace = namespace
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// offerTeamNamespaces asks whether to set the namespaces of the contexts of env to those of the teams in token, if any
+// context would change. Nothing is asked unless stdin is a terminal.
+func offerTeamNamespaces(authenticator *kubelogin.Authenticator, env, context string, token *kubelogin.Token) error {
+	if authenticator.Config.SkipTeamNamespacePrompt || !isTerminal(os.Stdin) {
+		return nil
+	}
+	teams, err := tokenTeams(authenticator, token)
+	if err != nil || len(teams) == 0 {
+		return err
+	}
+	file := kubeconfFileOf(env, context)
+	before, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed reading file %v: %w", file, err)
+	}
+	kubeconf, err := clientcmd.Load(before)
+	if err != nil {
+		return fmt.Errorf("failed parsing file %v: %w", file, err)
+	}
+	changed := setTeamNamespaces(kubeconf, context, authenticator.Config.TeamNamespaceFor(env), teams, true)
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, name := range changed {
+		_, _ = fmt.Fprintf(os.Stderr, "  %v: namespace %v\n", name, kubeconf.Contexts[name].Namespace)
+	}
+	if !confirm(fmt.Sprintf("Default to the namespaces of your teams (%v)?", strings.Join(teams, ", "))) {
+		_, _ = fmt.Fprintf(os.Stderr, "Run 'kubectl login init %v --team-namespaces' to do so later, or set "+
+			"skipTeamNamespacePrompt: true in %v to not be asked again\n", env, util.ConfigFile())
+		return nil
+	}
+	return writeKubeConf(env, file, before, kubeconf, false)
+}
+
+// kubeconfFileOf returns the kubeconfig file where context is defined, or ~/.kube/config.<env> if not found
+func kubeconfFileOf(env, context string) string {
+	if kubeconf, err := util.ReadConfigFromContext(context); err == nil {
+		if contextConf, ok := kubeconf.Contexts[context]; ok && contextConf.LocationOfOrigin != "" {
+			return contextConf.LocationOfOrigin
+		}
+	}
+	return clientcmd.RecommendedHomeFile + "." + env
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// initTeams logs in to each environment of spec if needed, and returns the teams of the user per environment
+func initTeams(ctx context.Context, spec string, loginCfg *util.Config) (map[string][]string, error) {
+	envs, err := resolveEnvironments(spec)
+	if err != nil {
+		return nil, err
+	}
+	authenticator := kubelogin.New(loginCfg)
+	teams := map[string][]string{}
+	for _, env := range envs {
+		token, err := authenticator.Token(ctx, env)
+		if err != nil {
+			return nil, fmt.Errorf("failed authenticating for %v to find your teams: %w", env, err)
+		}
+		if teams[env], err = tokenTeams(authenticator, token); err != nil {
+			return nil, err
+		}
+		if len(teams[env]) == 0 {
+			fmt.Printf("No team found in groups of your %v token - namespaces left as they are\n", env)
+		}
+	}
+	return teams, nil
+}
+
+func teamCmd(ctx context.Context, fs *flag.FlagSet) func([]string) error {
+	contextFlag := fs.String("context", "", "Derive the team context from `context` rather than the current context")
+	return func(args []string) error {
+		cmd := findCommand("team")
+		if err := cmd.expectArgs(args, 2, 2); err != nil {
+			return err
+		}
+		if args[0] != "use" {
+			return &usageError{command: cmd.name, msg: fmt.Sprintf("unknown team command %q, use %v", args[0],
+				cmd.args)}
+		}
+		team := args[1]
+		env, err := currentEnv(*contextFlag)
+		if err != nil {
+			return err
+		}
+		baseContext := util.EnvToContext(env)
+		authenticator, err := newAuthenticator("")
+		if err != nil {
+			return err
+		}
+		token, err := authenticator.Token(ctx, env)
+		if err != nil {
+			return err
+		}
+		username, group, err := impersonationOf(ctx, authenticator, env, baseContext, token, team)
+		if err != nil {
+			return err
+		}
+
+		file := kubeconfFileOf(env, baseContext)
+		before, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed reading file %v: %w", file, err)
+		}
+		kubeconf, err := clientcmd.Load(before)
+		if err != nil {
+			return fmt.Errorf("failed parsing file %v: %w", file, err)
+		}
+		namespace := util.TeamNamespace(authenticator.Config.TeamNamespaceFor(env), team)
+		name, err := setTeamContext(kubeconf, baseContext, team, username, group, namespace)
+		if err != nil {
+			return err
+		}
+		kubeconf.CurrentContext = name
+		if err = writeKubeConf(env, file, before, kubeconf, false); err != nil {
+			return err
+		}
+		fmt.Printf("Switched to context %v, acting as %v with the rights of group %v only. "+
+			"Run 'kubectl config use-context %v' to switch back.\n", name, username, group, baseContext)
+		return nil
+	}
+}
+
+// impersonationOf returns the username and the group of team, as seen by the API server of context, to impersonate in
+// order to act with the rights of team only. The username is found through the OIDC rules of env if configured, or
+// else by asking the API server.
+func impersonationOf(ctx context.Context, authenticator *kubelogin.Authenticator, env, context string,
+	token *kubelogin.Token, team string) (username, group string, err error) {
+	claims, err := util.ParseIdentityClaims(token.Raw, authenticator.Config)
+	if err != nil {
+		return "", "", &kubelogin.InvalidTokenError{Err: err}
+	}
+	if claims.Groups != nil {
+		if group, err = claims.Mapping.TeamGroup(*claims.Groups, team); err != nil {
+			return "", "", err
+		}
+	}
+	if group == "" {
+		return "", "", fmt.Errorf("not a member of team %v - your teams are %v", team,
+			strings.Join(util.ExtractTeams(claims), ", "))
+	}
+
+	user, err := authenticator.KubernetesUser(env, token)
+	if err != nil {
+		return "", "", err
+	}
+	if user != nil {
+		return user.Username, authenticator.Config.OIDCFor(env).GroupsPrefix + group, nil
+	}
+	identity, err := authenticator.ReviewTokenIdentity(ctx, context, token)
+	if err != nil {
+		return "", "", err
+	}
+	if identity.Partial {
+		return "", "", fmt.Errorf("the API server of %v doesn't tell your username - configure the oidc rules of "+
+			"%v in %v", context, env, util.ConfigFile())
+	}
+	return identity.Username, apiServerGroup(identity.Groups, *claims.Groups, group), nil
+}
+
+// apiServerGroup returns group the way the API server sees it among seen, as the API server may prefix the groups of
+// the token. A prefix is only taken to be that of the API server if all groups of the token are seen with it, so that
+// another group merely ending like group is never picked.
+func apiServerGroup(seen, groups []string, group string) string {
+	isSeen := make(map[string]bool, len(seen))
+	for _, name := range seen {
+		isSeen[name] = true
+	}
+	if isSeen[group] {
+		return group
+	}
+	for _, name := range seen {
+		if !strings.HasSuffix(name, group) {
+			continue
+		}
+		prefix, prefixed := strings.TrimSuffix(name, group), true
+		for _, tokenGroup := range groups {
+			prefixed = prefixed && isSeen[prefix+tokenGroup]
+		}
+		if prefixed {
+			return name
+		}
+	}
+	return group
+}
+
+// setTeamContext adds or updates the impersonation context of team in kubeconf, along with a user like that of context
+// but impersonating username in group only. The namespace of an existing impersonation context is kept. Returns the
+// name of the impersonation context.
+func setTeamContext(kubeconf *api.Config, context, team, username, group, namespace string) (string, error) {
+	base, ok := kubeconf.Contexts[context]
+	if !ok {
+		return "", fmt.Errorf("context %v not found - run 'kubectl login init %v' first", context,
+			util.ContextToEnv(context))
+	}
+	baseAuthInfo, ok := kubeconf.AuthInfos[base.AuthInfo]
+	if !ok {
+		return "", fmt.Errorf("user %v of context %v not found", base.AuthInfo, context)
+	}
+	name := impersonationContext(context, team)
+	authInfo := baseAuthInfo.DeepCopy()
+	authInfo.Impersonate = username
+	authInfo.ImpersonateGroups = []string{group}
+	kubeconf.AuthInfos[name] = authInfo
+
+	contextConf, ok := kubeconf.Contexts[name]
+	if !ok {
+		contextConf = api.NewContext()
+		contextConf.Namespace = namespace
+		kubeconf.Contexts[name] = contextConf
+	}
+	contextConf.Cluster = base.Cluster
+	contextConf.AuthInfo = name
+	return name, nil
+}
+
+// activeTeam returns the team and group impersonated by context in clientCfg, if it's an impersonation context made by
+// team use
+func activeTeam(clientCfg *api.Config, context string) (team, group string) {
+	contextConf, ok := clientCfg.Contexts[context]
+	if !ok || util.BaseContext(context) == context {
+		return "", ""
+	}
+	authInfo, ok := clientCfg.AuthInfos[contextConf.AuthInfo]
+	if !ok || len(authInfo.ImpersonateGroups) == 0 {
+		return "", ""
+	}
+	team = strings.TrimSuffix(strings.TrimPrefix(context, util.BaseContext(context)+"/"), "@impersonate")
+	return team, strings.Join(authInfo.ImpersonateGroups, ", ")
+}
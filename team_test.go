@@ -0,0 +1,148 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Bisnode/kubectl-login/util"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func teamTestKubeconf(ctx, namespace string) *api.Config {
+	kubeconf := api.NewConfig()
+	kubeconf.Contexts[ctx] = &api.Context{Cluster: ctx, AuthInfo: ctx, Namespace: namespace}
+	return kubeconf
+}
+
+func TestSingleTeamSetsNamespaceOfContext(t *testing.T) {
+	ctx := util.EnvToContext("dev")
+	kubeconf := teamTestKubeconf(ctx, "default")
+
+	changed := setTeamNamespaces(kubeconf, ctx, "{name}", []string{"team-cool-runners"}, true)
+	if !reflect.DeepEqual([]string{ctx}, changed) || kubeconf.Contexts[ctx].Namespace != "cool-runners" {
+		t.Errorf("Expected namespace of %v to be set, changed %v", ctx, changed)
+	}
+	if changed = setTeamNamespaces(kubeconf, ctx, "{name}", []string{"team-cool-runners"}, true); changed != nil {
+		t.Errorf("Expected nothing to change, changed %v", changed)
+	}
+}
+
+func TestSeveralTeamsGetAContextEach(t *testing.T) {
+	ctx := util.EnvToContext("dev")
+	kubeconf := teamTestKubeconf(ctx, "")
+
+	changed := setTeamNamespaces(kubeconf, ctx, "{team}-ns", []string{"team-lunatics", "team-cool-runners"}, true)
+	expected := []string{ctx + "/team-cool-runners", ctx + "/team-lunatics"}
+	if !reflect.DeepEqual(expected, changed) {
+		t.Errorf("Expected contexts %v to change, changed %v", expected, changed)
+	}
+	lunatics := kubeconf.Contexts[ctx+"/team-lunatics"]
+	if lunatics.Namespace != "team-lunatics-ns" || lunatics.AuthInfo != ctx || lunatics.Cluster != ctx {
+		t.Errorf("Unexpected team context %+v", lunatics)
+	}
+	if kubeconf.Contexts[ctx].Namespace != "" {
+		t.Errorf("Expected namespace of %v to be left as is", ctx)
+	}
+}
+
+func TestNamespacesSetByHandAreKeptUnlessAsked(t *testing.T) {
+	ctx := util.EnvToContext("dev")
+	kubeconf := teamTestKubeconf(ctx, "playground")
+
+	if changed := setTeamNamespaces(kubeconf, ctx, "{name}", []string{"team-lunatics"}, true); changed != nil {
+		t.Errorf("Expected namespace set by hand to be kept, changed %v", changed)
+	}
+	setTeamNamespaces(kubeconf, ctx, "{name}", []string{"team-lunatics"}, false)
+	if namespace := kubeconf.Contexts[ctx].Namespace; namespace != "lunatics" {
+		t.Errorf("Expected namespace to be set, got %v", namespace)
+	}
+}
+
+func TestInitWithTeamsSetsNamespaces(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	opts := initOptions{target: file, config: &util.Config{}, teams: map[string][]string{"dev": {"team-lunatics"}}}
+	if err := initKubeConfContext("dev", api.NewConfig(), true, opts); err != nil {
+		t.Fatal(err)
+	}
+	kubeconf, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace := kubeconf.Contexts[util.EnvToContext("dev")].Namespace; namespace != "lunatics" {
+		t.Errorf("Expected namespace of team, got %q", namespace)
+	}
+}
+
+func TestTeamContextImpersonatesTeamGroupOnly(t *testing.T) {
+	ctx := util.EnvToContext("dev")
+	kubeconf := teamTestKubeconf(ctx, "default")
+	kubeconf.AuthInfos[ctx] = &api.AuthInfo{Exec: &api.ExecConfig{Command: "kubectl-login",
+		Args: []string{"--print", "--context=" + ctx}}}
+	// The team context of team namespaces, acting with the rights of all teams
+	setTeamNamespaces(kubeconf, ctx, "{name}", []string{"team-lunatics", "team-cool-runners"}, false)
+
+	name, err := setTeamContext(kubeconf, ctx, "team-lunatics", "oidc:bobby@bisnode.com",
+		"oidc:sec-tbac-team-lunatics", "lunatics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authInfo := kubeconf.AuthInfos[name]
+	if authInfo.Exec == nil || authInfo.Exec.Args[1] != "--context="+ctx {
+		t.Errorf("Expected exec plugin of %v to be kept, got %+v", ctx, authInfo.Exec)
+	}
+	if authInfo.Impersonate != "oidc:bobby@bisnode.com" ||
+		!reflect.DeepEqual([]string{"oidc:sec-tbac-team-lunatics"}, authInfo.ImpersonateGroups) {
+		t.Errorf("Unexpected impersonation %v %v", authInfo.Impersonate, authInfo.ImpersonateGroups)
+	}
+	if kubeconf.AuthInfos[ctx].Impersonate != "" {
+		t.Error("Expected user of base context to be left as is")
+	}
+	if name != ctx+"/team-lunatics@impersonate" {
+		t.Errorf("Expected impersonation context named apart from the team context, got %v", name)
+	}
+	if namespaceContext := kubeconf.Contexts[teamContext(ctx, "team-lunatics")]; namespaceContext.AuthInfo != ctx {
+		t.Errorf("Expected team context to keep the user of %v, got %v", ctx, namespaceContext.AuthInfo)
+	}
+	contextConf := kubeconf.Contexts[name]
+	if contextConf.AuthInfo != name || contextConf.Cluster != ctx || contextConf.Namespace != "lunatics" {
+		t.Errorf("Unexpected team context %+v", contextConf)
+	}
+	if !util.IsKnownContext(name) || util.ContextToEnv(name) != "dev" {
+		t.Errorf("Expected team context %v to be known as a context of dev", name)
+	}
+
+	if team, group := activeTeam(kubeconf, name); team != "team-lunatics" || group != "oidc:sec-tbac-team-lunatics" {
+		t.Errorf("Expected team context to be active, got %v %v", team, group)
+	}
+	if team, _ := activeTeam(kubeconf, ctx); team != "" {
+		t.Errorf("Expected no team for base context, got %v", team)
+	}
+}
+
+func TestTeamContextRequiresBaseContext(t *testing.T) {
+	if _, err := setTeamContext(api.NewConfig(), util.EnvToContext("dev"), "team-lunatics", "bobby", "group",
+		"lunatics"); err == nil {
+		t.Error("Expected missing base context to fail")
+	}
+}
+
+func TestAPIServerGroup(t *testing.T) {
+	groups := []string{"team-lunatics", "sub-team-lunatics"}
+	tests := []struct {
+		name     string
+		seen     []string
+		expected string
+	}{
+		{name: "unprefixed", seen: []string{"sub-team-lunatics", "team-lunatics"}, expected: "team-lunatics"},
+		{name: "prefixed", seen: []string{"oidc:sub-team-lunatics", "oidc:team-lunatics", "system:authenticated"},
+			expected: "oidc:team-lunatics"},
+		{name: "ending alike only", seen: []string{"sub-team-lunatics"}, expected: "team-lunatics"},
+	}
+	for _, test := range tests {
+		if group := apiServerGroup(test.seen, groups, "team-lunatics"); group != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.name, test.expected, group)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bisnode/kubectl-login/pkg/kubelogin"
+	"github.com/Bisnode/kubectl-login/util"
+)
+
+// timeClaims are the claims holding seconds since epoch, printed in human-readable form as well
+var timeClaims = map[string]bool{"exp": true, "iat": true, "nbf": true, "auth_time": true}
+
+// tokenFormats are the formats token prints tokens in
+var tokenFormats = []string{"raw", "header", "env", "json"}
+
+// printToken prints token in format: raw as is, header as an Authorization header, env as an export of KUBE_TOKEN
+// for POSIX shells, and json as an object holding the token and its expiry
+func printToken(w io.Writer, format string, token *kubelogin.Token) error {
+	switch format {
+	case "header":
+		_, err := fmt.Fprintf(w, "Authorization: Bearer %v\n", token.Raw)
+		return err
+	case "env":
+		_, err := fmt.Fprintf(w, "export KUBE_TOKEN=%v\n", token.Raw)
+		return err
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Token  string    `json:"token"`
+			Expiry time.Time `json:"expiry"`
+		}{token.Raw, token.Expiry.UTC()})
+	}
+	_, err := fmt.Fprintln(w, token.Raw)
+	return err
+}
+
+// decodeOptions controls where token decode reads a token from, and what it prints
+type decodeOptions struct {
+	// file to read the token from, "-" for stdin. If empty, stdin is read unless a terminal, in which case the stored
+	// token of env, or else of context, is decoded.
+	file string
+	// env is the environment whose issuer the signature is verified against, rather than the environment of the issuer
+	// claimed by the token
+	env           string
+	context       string
+	showSignature bool
+	offline       bool
+}
+
+// decodeToken prints the header and claims of a token, along with its expiry and signature verification status
+func decodeToken(ctx context.Context, opts decodeOptions) error {
+	authenticator, err := newAuthenticator("")
+	if err != nil {
+		return err
+	}
+	raw, err := readTokenInput(authenticator, opts)
+	if err != nil {
+		return err
+	}
+
+	verification := "not verified (--offline)"
+	if !opts.offline {
+		verification = verifySignature(ctx, authenticator, opts.env, raw)
+	}
+	return printDecodedToken(os.Stdout, raw, authenticator.Clock(), opts.showSignature, verification)
+}
+
+func readTokenInput(authenticator *kubelogin.Authenticator, opts decodeOptions) (string, error) {
+	var input []byte
+	var err error
+	switch {
+	case opts.file == "-" || opts.file == "" && !isTerminal(os.Stdin):
+		input, err = ioutil.ReadAll(os.Stdin)
+	case opts.file != "":
+		input, err = ioutil.ReadFile(opts.file)
+	default:
+		env := opts.env
+		if env == "" {
+			if env, err = currentEnv(opts.context); err != nil {
+				return "", err
+			}
+		}
+		status, err := authenticator.Status(env)
+		if err != nil {
+			return "", err
+		}
+		if status.Token == nil {
+			return "", kubelogin.ErrNotLoggedIn
+		}
+		return status.Token.Raw, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed reading token: %w", err)
+	}
+	raw := strings.TrimPrefix(strings.TrimSpace(string(input)), "Bearer ")
+	if raw == "" {
+		return "", errors.New("no token given")
+	}
+	return raw, nil
+}
+
+// verifySignature describes whether the signature of raw is valid, using the HTTP client of env. If env is empty, the
+// environment of the issuer of raw is used, and tokens not issued by the issuer of a known environment are not
+// verified.
+func verifySignature(ctx context.Context, authenticator *kubelogin.Authenticator, env, raw string) string {
+	claims, err := util.ParseIdentityClaims(raw, nil)
+	if err != nil {
+		return "not verified: " + err.Error()
+	}
+	// The issuer claimed by the token can't be trusted, so only the issuers of known environments are asked for keys
+	for _, known := range util.KnownEnvironments() {
+		if env == "" && authenticator.Issuer(known).Name == claims.Issuer {
+			env = known
+		}
+	}
+	if env == "" {
+		return "not verified: unknown issuer"
+	}
+	client, err := authenticator.HTTPClientFor(env)
+	if err != nil {
+		return "not verified: " + err.Error()
+	}
+	kid, err := kubelogin.VerifySignature(ctx, client, authenticator.Issuer(env).Name, raw)
+	switch {
+	case errors.Is(err, kubelogin.ErrNoJWKS):
+		return "not verified: " + err.Error()
+	case err != nil:
+		return "INVALID: " + err.Error()
+	case kid != "":
+		return fmt.Sprintf("valid (key %v)", kid)
+	}
+	return "valid"
+}
+
+// printDecodedToken prints the header and claims of raw, with times in human-readable form, followed by the expiry
+// status of the token at now and its signature verification status. The signature is redacted unless showSignature.
+func printDecodedToken(w io.Writer, raw string, now time.Time, showSignature bool, verification string) error {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return &kubelogin.InvalidTokenError{Err: fmt.Errorf("expected 3 parts separated by dots, got %v",
+			len(parts))}
+	}
+	header, err := decodeSegment(parts[0])
+	if err != nil {
+		return &kubelogin.InvalidTokenError{Err: fmt.Errorf("invalid header: %w", err)}
+	}
+	claims, err := decodeSegment(parts[1])
+	if err != nil {
+		return &kubelogin.InvalidTokenError{Err: fmt.Errorf("invalid claims: %w", err)}
+	}
+
+	_, _ = fmt.Fprintln(w, "Header:")
+	printSegment(w, header)
+	_, _ = fmt.Fprintln(w, "Claims:")
+	printSegment(w, claims)
+
+	signature := "<redacted, use --show-signature to show>"
+	if showSignature {
+		signature = parts[2]
+	}
+	_, _ = fmt.Fprintf(w, "Signature: %v\n", signature)
+	_, _ = fmt.Fprintf(w, "Expiry: %v\n", expiryOf(claims, now))
+	_, _ = fmt.Fprintf(w, "Signature verification: %v\n", verification)
+	return nil
+}
+
+func decodeSegment(segment string) (map[string]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	values := map[string]interface{}{}
+	if err = decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// printSegment prints values sorted by key, strings as is and anything else as JSON
+func printSegment(w io.Writer, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(values[key])
+		if _, isString := values[key].(string); !isString {
+			encoded, _ := json.Marshal(values[key])
+			value = string(encoded)
+		}
+		if seconds, ok := values[key].(json.Number); ok && timeClaims[key] {
+			if unix, err := seconds.Int64(); err == nil {
+				value += " (" + time.Unix(unix, 0).UTC().Format(time.RFC3339) + ")"
+			}
+		}
+		_, _ = fmt.Fprintf(w, "  %v: %v\n", key, value)
+	}
+}
+
+func expiryOf(claims map[string]interface{}, now time.Time) string {
+	seconds, ok := claims["exp"].(json.Number)
+	if !ok {
+		return "never expires (no exp claim)"
+	}
+	unix, err := seconds.Int64()
+	if err != nil {
+		return "invalid exp claim"
+	}
+	left := time.Unix(unix, 0).Sub(now).Round(time.Second)
+	if left <= 0 {
+		return fmt.Sprintf("EXPIRED %v ago", -left)
+	}
+	return fmt.Sprintf("valid for another %v", left)
+}
@@ -0,0 +1,208 @@
+package util
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// FetchCertificateChain returns the certificate chain presented by the server at serverURL, without verifying it. This
+// is only meant for showing the certificates to the user, e.g. to establish trust on first use. The server is connected
+// to through the SOCKS5 or HTTP proxy at proxyURL, unless empty.
+func FetchCertificateChain(serverURL, proxyURL string) ([]*x509.Certificate, error) {
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	address := parsed.Host
+	if parsed.Port() == "" {
+		address = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+	rawConn, err := dialThrough(proxyURL, address, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to %v: %w", address, err)
+	}
+	conn := tls.Client(rawConn, &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec
+		ServerName:         parsed.Hostname(),
+	})
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err = conn.Handshake(); err != nil {
+		return nil, fmt.Errorf("failed connecting to %v: %w", address, err)
+	}
+
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificates presented by %v", address)
+	}
+	return chain, nil
+}
+
+// dialThrough connects to address through the SOCKS5 or HTTP proxy at proxyURL, or directly if proxyURL is empty
+func dialThrough(proxyURL, address string, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if proxyURL == "" {
+		return dialer.Dial("tcp", address)
+	}
+	proxyAddress, err := ProxyAddress(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := url.Parse(proxyURL)
+	if parsed.Scheme == "socks5" || parsed.Scheme == "socks5h" {
+		var auth *proxy.Auth
+		if parsed.User != nil {
+			password, _ := parsed.User.Password()
+			auth = &proxy.Auth{User: parsed.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyAddress, auth, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.Dial("tcp", address)
+	}
+
+	conn, err := dialer.Dial("tcp", proxyAddress)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: parsed.Hostname()})
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	connect := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: address}, Host: address,
+		Header: http.Header{}}
+	if parsed.User != nil {
+		password, _ := parsed.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(parsed.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err = connect.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed connecting through proxy %v: %w", proxyAddress, err)
+	}
+	// Nothing but the response is sent before the TLS handshake, so nothing is lost by reading it through a buffer
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed connecting through proxy %v: %w", proxyAddress, err)
+	}
+	// The body of a successful response is the tunnel, so it's not closed
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy %v refused connecting to %v: %v", proxyAddress, address, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// TrustAnchor returns the certificate of chain to trust - the last one presented, which is the CA if the server
+// includes it, or else the server certificate itself
+func TrustAnchor(chain []*x509.Certificate) *x509.Certificate {
+	return chain[len(chain)-1]
+}
+
+// Fingerprint returns the SHA-256 fingerprint of cert, in the colon separated form also printed by openssl
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
+
+// FingerprintMatches returns true if fingerprint is that of cert, ignoring case and colons
+func FingerprintMatches(cert *x509.Certificate, fingerprint string) bool {
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	}
+	return fingerprint != "" && normalize(fingerprint) == normalize(Fingerprint(cert))
+}
+
+// EncodeCertificates returns certs PEM encoded, as in the certificate-authority-data of kubeconf
+func EncodeCertificates(certs ...*x509.Certificate) []byte {
+	var encoded []byte
+	for _, cert := range certs {
+		encoded = append(encoded, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return encoded
+}
+
+// ParseCertificates returns all certificates of PEM encoded data
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+	return certs, nil
+}
+
+// ContainsCertificate returns true if cert is one of the PEM encoded certificates of data
+func ContainsCertificate(data []byte, cert *x509.Certificate) bool {
+	certs, err := ParseCertificates(data)
+	if err != nil {
+		return false
+	}
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterCaCertificates returns the CA certificates known for the cluster of context, as provided by ClusterCaCert
+func ClusterCaCertificates(context string) ([]*x509.Certificate, error) {
+	caCert := ClusterCaCert(context)
+	if caCert == "unknown" {
+		return nil, fmt.Errorf("no CA certificate known for cluster %v", context)
+	}
+	data, err := base64.StdEncoding.DecodeString(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CA certificate for cluster %v: %w", context, err)
+	}
+	return ParseCertificates(data)
+}
+
+// VerifyChain verifies that the chain presented by a server is signed by one of roots. Host names are not verified, as
+// only the CA is of interest here.
+func VerifyChain(chain []*x509.Certificate, roots []*x509.Certificate) error {
+	opts := x509.VerifyOptions{Roots: x509.NewCertPool(), Intermediates: x509.NewCertPool()}
+	for _, root := range roots {
+		opts.Roots.AddCert(root)
+	}
+	for _, intermediate := range chain[1:] {
+		opts.Intermediates.AddCert(intermediate)
+	}
+	_, err := chain[0].Verify(opts)
+	return err
+}
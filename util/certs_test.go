@@ -0,0 +1,174 @@
+package util
+
+import (
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchedCertificateCanBePinnedByFingerprint(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	chain, err := FetchCertificateChain(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchor := TrustAnchor(chain)
+	if !anchor.Equal(server.Certificate()) {
+		t.Errorf("Expected certificate of test server, got %v", anchor.Subject)
+	}
+
+	fingerprint := Fingerprint(anchor)
+	if !FingerprintMatches(anchor, strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))) {
+		t.Errorf("Expected fingerprint %v to match regardless of case and colons", fingerprint)
+	}
+	if FingerprintMatches(anchor, "AB:CD") || FingerprintMatches(anchor, "") {
+		t.Error("Expected other fingerprints not to match")
+	}
+
+	pinned := EncodeCertificates(anchor)
+	if !ContainsCertificate(pinned, anchor) {
+		t.Error("Expected PEM encoded certificate to contain the pinned certificate")
+	}
+}
+
+func TestVerifyChainAgainstKnownCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	chain, err := FetchCertificateChain(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyChain(chain, []*x509.Certificate{server.Certificate()}); err != nil {
+		t.Errorf("Expected chain to be signed by test server CA, got %v", err)
+	}
+
+	known, err := ClusterCaCertificates("tr.k8s.dev.blue.bisnode.net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyChain(chain, known); err == nil {
+		t.Error("Expected chain not to be signed by the dev cluster CA")
+	}
+	if _, err = ClusterCaCertificates("tr.k8s.unknown.blue.bisnode.net"); err == nil {
+		t.Error("Expected no CA for unknown cluster")
+	}
+}
+
+func TestFetchCertificateChainThroughProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var connects int32
+	httpProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT supported", http.StatusMethodNotAllowed)
+			return
+		}
+		atomic.AddInt32(&connects, 1)
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = upstream.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, upstream)
+	}))
+	defer httpProxy.Close()
+	socksProxy := socks5Proxy(t, &connects)
+
+	for _, proxyURL := range []string{httpProxy.URL, "socks5://" + socksProxy} {
+		before := atomic.LoadInt32(&connects)
+		chain, err := FetchCertificateChain(server.URL, proxyURL)
+		if err != nil {
+			t.Fatalf("Failed fetching through %v: %v", proxyURL, err)
+		}
+		if !TrustAnchor(chain).Equal(server.Certificate()) {
+			t.Errorf("Expected certificate of test server through %v", proxyURL)
+		}
+		if atomic.LoadInt32(&connects) != before+1 {
+			t.Errorf("Expected connection through %v", proxyURL)
+		}
+	}
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+// socks5Proxy serves the no authentication CONNECT subset of SOCKS5, returning its address
+func socks5Proxy(t *testing.T, connects *int32) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				// Greeting: version, number of methods and methods, answered with no authentication required
+				greeting := make([]byte, 2)
+				if _, err := io.ReadFull(conn, greeting); err != nil {
+					_ = conn.Close()
+					return
+				}
+				_, _ = io.ReadFull(conn, make([]byte, greeting[1]))
+				_, _ = conn.Write([]byte{5, 0})
+				// Request: version, command, reserved, address type and address, followed by the port
+				request := make([]byte, 4)
+				_, _ = io.ReadFull(conn, request)
+				var host string
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					_, _ = io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					_, _ = io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					_, _ = io.ReadFull(conn, name)
+					host = string(name)
+				}
+				port := make([]byte, 2)
+				_, _ = io.ReadFull(conn, port)
+				atomic.AddInt32(connects, 1)
+				address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+				upstream, err := net.Dial("tcp", address)
+				if err != nil {
+					_, _ = conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					_ = conn.Close()
+					return
+				}
+				_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				pipe(conn, upstream)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ParseIdentityClaims parses the claims of rawToken without verifying it, mapping them to username and groups as
+// configured in conf for the issuer of the token. If conf is nil, the default mapping is used.
+func ParseIdentityClaims(rawToken string, conf *Config) (*IdentityClaims, error) {
+	parser := &jwt.Parser{}
+	claims := &IdentityClaims{}
+	if _, _, err := parser.ParseUnverified(rawToken, &claims.StandardClaims); err != nil {
+		return nil, err
+	}
+	all := jwt.MapClaims{}
+	if _, _, err := parser.ParseUnverified(rawToken, all); err != nil {
+		return nil, err
+	}
+	if conf != nil {
+		claims.Mapping = conf.ClaimMappingFor(claims.Issuer)
+	}
+
+	usernameClaim := claims.Mapping.usernameClaim()
+	if username, ok := claimAt(all, usernameClaim).(string); ok {
+		claims.Username = username
+	}
+	switch groups := claimAt(all, claims.Mapping.groupsClaim()).(type) {
+	case nil:
+	case string:
+		claims.Groups = &[]string{groups}
+	case []interface{}:
+		names := make([]string, 0, len(groups))
+		for _, group := range groups {
+			name, ok := group.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %q is not a list of strings", claims.Mapping.groupsClaim())
+			}
+			names = append(names, name)
+		}
+		claims.Groups = &names
+	default:
+		return nil, fmt.Errorf("claim %q is neither a string nor a list", claims.Mapping.groupsClaim())
+	}
+	return claims, nil
+}
+
+// claimAt returns the claim at path, where nested claims are separated by dots, or nil if not found. A claim named like
+// the whole path wins over a nested claim.
+func claimAt(claims map[string]interface{}, path string) interface{} {
+	if value, ok := claims[path]; ok {
+		return value
+	}
+	name, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		return nil
+	}
+	if inner, ok := claims[name].(map[string]interface{}); ok {
+		return claimAt(inner, rest)
+	}
+	return nil
+}
+
+func (m ClaimMapping) usernameClaim() string {
+	if m.UsernameClaim != "" {
+		return m.UsernameClaim
+	}
+	return "email"
+}
+
+func (m ClaimMapping) groupsClaim() string {
+	if m.GroupsClaim != "" {
+		return m.GroupsClaim
+	}
+	return "groups"
+}
+
+// Teams returns the teams of groups. Groups are compared in lower case.
+func (m ClaimMapping) Teams(groups []string) ([]string, error) {
+	pattern := m.TeamPattern
+	if pattern == "" && m.TeamPrefix != "" {
+		pattern = "^" + regexp.QuoteMeta(strings.ToLower(m.TeamPrefix)) + "(.+)$"
+	}
+	if pattern == "" {
+		pattern = "^sec-tbac-(team-.+)$"
+	}
+	teamRegexp, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid team pattern: %w", err)
+	}
+	rewrites := make([]*regexp.Regexp, len(m.TeamRewrites))
+	for i, rewrite := range m.TeamRewrites {
+		if rewrites[i], err = regexp.Compile(rewrite.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid team rewrite pattern: %w", err)
+		}
+	}
+
+	var teams []string
+	for _, group := range groups {
+		match := teamRegexp.FindStringSubmatch(strings.ToLower(group))
+		if match == nil {
+			continue
+		}
+		team := match[0]
+		if len(match) > 1 {
+			team = match[1]
+		}
+		for i, rewrite := range rewrites {
+			team = rewrite.ReplaceAllString(team, m.TeamRewrites[i].Replacement)
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// TeamGroup returns the group of groups that team is extracted from, or an empty string if team is not among the teams
+// of groups
+func (m ClaimMapping) TeamGroup(groups []string, team string) (string, error) {
+	for _, group := range groups {
+		teams, err := m.Teams([]string{group})
+		if err != nil {
+			return "", err
+		}
+		if len(teams) == 1 && teams[0] == team {
+			return group, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,305 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// Config is the optional user configuration, read from ~/.kube/kubectl-login/config.yaml
+type Config struct {
+	// Browser used for all environments unless overridden, see browser.FromSpec for accepted values
+	Browser string `json:"browser,omitempty"`
+	// CAExpiryWarningDays is the number of days before expiry of a cluster CA that logins start warning about it,
+	// 30 if not set
+	CAExpiryWarningDays int `json:"caExpiryWarningDays,omitempty"`
+	// HTTP configures requests to the identity provider of all environments, unless overridden per environment
+	HTTP HTTPConfig `json:"http,omitempty"`
+	// VerifyLogin makes logins verify new tokens with the API server, through a SelfSubjectReview
+	VerifyLogin bool `json:"verifyLogin,omitempty"`
+	// TeamNamespace is the namespace of a team for all environments unless overridden, see TeamNamespace
+	TeamNamespace string `json:"teamNamespace,omitempty"`
+	// SkipTeamNamespacePrompt stops logins from offering to set the namespaces of contexts to those of your teams
+	SkipTeamNamespacePrompt bool `json:"skipTeamNamespacePrompt,omitempty"`
+	// OIDC mirrors the OIDC flags of the API servers of all environments, unless overridden per environment
+	OIDC OIDCConfig `json:"oidc,omitempty"`
+	// Claims maps the claims of tokens issued by an issuer, keyed by issuer URL, to username, groups and teams. Tokens
+	// of issuers not configured are mapped the Common Login way, see ClaimMapping.
+	Claims       map[string]*ClaimMapping `json:"claims,omitempty"`
+	Environments map[string]*EnvConfig    `json:"environments,omitempty"`
+}
+
+// EnvConfig holds settings for a single environment (dev, qa, etc). Environments not known to kubectl login may be
+// added by configuring them, see ConfigureEnvironments.
+type EnvConfig struct {
+	// Context is the name of the context, cluster and user of the environment in kubeconf
+	Context string `json:"context,omitempty"`
+	// Account is the account color (blue, orange) of the cluster, used to name its context unless Context is set
+	Account string `json:"account,omitempty"`
+	// Server is the URL of the API server, https://api.${context} if not set
+	Server string `json:"server,omitempty"`
+	// Issuer is the URL of the identity provider issuing tokens for the environment, like https://login.bisnode.com.
+	// Required for environments not known to kubectl login.
+	Issuer string `json:"issuer,omitempty"`
+	// AuthorizeEndpoint is the authorization endpoint of Issuer, ${issuer}/as/authorization.oauth2 if not set
+	AuthorizeEndpoint string `json:"authorizeEndpoint,omitempty"`
+	// Default makes the environment current-context when initializing several environments
+	Default bool `json:"default,omitempty"`
+	// Browser allows e.g. prod logins to open in a dedicated admin browser profile
+	Browser string `json:"browser,omitempty"`
+	// Probes are run before opening the browser, to tell whether the environment is reachable from the current network.
+	// Defaults are used if nil, while an empty list disables the probes.
+	Probes []Probe `json:"probes,omitempty"`
+	// HTTP configures requests to the identity provider of the environment, overriding the global settings
+	HTTP HTTPConfig `json:"http,omitempty"`
+	// ProxyURL is the SOCKS or HTTP proxy, like a bastion, through which the cluster of the environment is reached. It
+	// is written to kubeconf by init, and used for requests to the identity provider unless HTTP.Proxy is set.
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// OIDC mirrors the OIDC flags of the API server of the environment, overriding the global settings
+	OIDC OIDCConfig `json:"oidc,omitempty"`
+	// TeamNamespace is the namespace of a team in the environment, overriding the global setting
+	TeamNamespace string `json:"teamNamespace,omitempty"`
+}
+
+// OIDCConfig mirrors the --oidc-* flags of an API server, allowing tokens to be checked the way the API server would
+// check them, and to tell which user and groups the API server would see. No checks are made unless any rule is set.
+type OIDCConfig struct {
+	// IssuerURL must match the iss claim (--oidc-issuer-url). Configured rules default to the issuer of their
+	// environment, which global rules always check against, as environments have different issuers.
+	IssuerURL string `json:"issuerURL,omitempty"`
+	// ClientID must be one of the audiences of the token (--oidc-client-id)
+	ClientID string `json:"clientID,omitempty"`
+	// UsernameClaim is the claim used as username, "sub" if not set (--oidc-username-claim)
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+	// UsernamePrefix is prepended to usernames, "-" for none. Unless the username claim is "email", usernames are
+	// prefixed with the issuer URL and "#" if not set. (--oidc-username-prefix)
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+	// GroupsClaim is the claim used as groups (--oidc-groups-claim)
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+	// GroupsPrefix is prepended to groups (--oidc-groups-prefix)
+	GroupsPrefix string `json:"groupsPrefix,omitempty"`
+	// RequiredClaims must be present in tokens with the given string values, like aud: kubectl-login
+	// (--oidc-required-claim)
+	RequiredClaims map[string]string `json:"requiredClaims,omitempty"`
+}
+
+// ClaimMapping tells where to find the username, groups and teams of a user in the claims of a token. Unset fields
+// default to how Common Login issues tokens: username in email, groups in groups, and teams being the groups named
+// sec-tbac-team-*, without the sec-tbac- prefix.
+type ClaimMapping struct {
+	// UsernameClaim is the claim used as username, "email" if not set
+	UsernameClaim string `json:"usernameClaim,omitempty"`
+	// GroupsClaim is the claim used as groups, "groups" if not set. Nested claims are given as a path, like
+	// realm_access.roles.
+	GroupsClaim string `json:"groupsClaim,omitempty"`
+	// TeamPrefix makes groups starting with the prefix teams, named like the group without the prefix
+	TeamPrefix string `json:"teamPrefix,omitempty"`
+	// TeamPattern is a regular expression making matching groups teams, named like the first submatch or else the whole
+	// match. Wins over TeamPrefix if both are set.
+	TeamPattern string `json:"teamPattern,omitempty"`
+	// TeamRewrites are applied in order to the name of each team
+	TeamRewrites []Rewrite `json:"teamRewrites,omitempty"`
+}
+
+// Rewrite replaces matches of the regular expression Pattern with Replacement, which may refer to submatches like $1
+type Rewrite struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// HTTPConfig configures the HTTP client used for requests to an identity provider
+type HTTPConfig struct {
+	// Proxy is the URL of the proxy to use. If empty, HTTPS_PROXY and NO_PROXY of the environment are respected.
+	Proxy string `json:"proxy,omitempty"`
+	// CABundle is a file of PEM encoded CAs trusted in addition to the system CAs, like a TLS inspection CA
+	CABundle string `json:"caBundle,omitempty"`
+	// Timeout of each request, like "10s". Defaults to 30 seconds.
+	Timeout string `json:"timeout,omitempty"`
+	// Retries of requests failing with network errors or 502, 503 or 504 responses. Only requests without a body are
+	// retried. Defaults to 2.
+	Retries *int `json:"retries,omitempty"`
+}
+
+// Probe checks one aspect of reachability. Exactly one of DNS, TCP and HTTPS should be set.
+type Probe struct {
+	// DNS is a host name that must resolve
+	DNS string `json:"dns,omitempty"`
+	// TCP is a host:port that must accept connections
+	TCP string `json:"tcp,omitempty"`
+	// HTTPS is a URL that must respond with a 2xx status to GET requests
+	HTTPS string `json:"https,omitempty"`
+	// Timeout of the probe, like "5s". Defaults to 3 seconds.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// String returns the probe in the form kind:target
+func (p Probe) String() string {
+	switch {
+	case p.DNS != "":
+		return "dns:" + p.DNS
+	case p.TCP != "":
+		return "tcp:" + p.TCP
+	case p.HTTPS != "":
+		return "https:" + p.HTTPS
+	}
+	return "empty probe"
+}
+
+// TimeoutDuration returns the timeout of the probe, or 3 seconds if not set
+func (p Probe) TimeoutDuration() (time.Duration, error) {
+	if p.Timeout == "" {
+		return 3 * time.Second, nil
+	}
+	return time.ParseDuration(p.Timeout)
+}
+
+// ConfigFile returns the path of the user configuration file
+func ConfigFile() string {
+	return filepath.Join(configDir, "config.yaml")
+}
+
+// LoadConfig reads the user configuration. A missing file is not an error, but results in an empty configuration.
+func LoadConfig() (*Config, error) {
+	return LoadConfigFile(ConfigFile())
+}
+
+// LoadConfigFile reads the user configuration from file
+func LoadConfigFile(file string) (*Config, error) {
+	conf := &Config{}
+	bytes, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(bytes, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Env returns configuration for env, or an empty configuration if none provided
+func (c *Config) Env(env string) *EnvConfig {
+	if envConf, ok := c.Environments[env]; ok && envConf != nil {
+		return envConf
+	}
+	return &EnvConfig{}
+}
+
+// BrowserFor returns the browser spec to use for env. An environment specific browser wins over the global one, which
+// in turn wins over the KUBECTL_LOGIN_BROWSER environment variable.
+func (c *Config) BrowserFor(env string) string {
+	if browser := c.Env(env).Browser; browser != "" {
+		return browser
+	}
+	if c.Browser != "" {
+		return c.Browser
+	}
+	return os.Getenv("KUBECTL_LOGIN_BROWSER")
+}
+
+// CAExpiryWarning returns how long before expiry of a cluster CA that logins should warn about it
+func (c *Config) CAExpiryWarning() time.Duration {
+	days := c.CAExpiryWarningDays
+	if days == 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// HTTPFor returns the HTTP client configuration of env, where each setting of the environment wins over the global one
+func (c *Config) HTTPFor(env string) HTTPConfig {
+	conf := c.HTTP
+	envConf := c.Env(env).HTTP
+	if envConf.Proxy != "" {
+		conf.Proxy = envConf.Proxy
+	} else if proxyURL := c.Env(env).ProxyURL; proxyURL != "" {
+		conf.Proxy = proxyURL
+	}
+	if envConf.CABundle != "" {
+		conf.CABundle = envConf.CABundle
+	}
+	if envConf.Timeout != "" {
+		conf.Timeout = envConf.Timeout
+	}
+	if envConf.Retries != nil {
+		conf.Retries = envConf.Retries
+	}
+	return conf
+}
+
+// ProxyAddress returns the host:port of proxyURL, using the default port of its scheme if none is given
+func ProxyAddress(proxyURL string) (string, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Hostname() == "" {
+		return "", fmt.Errorf("no host in proxy URL %v", proxyURL)
+	}
+	if parsed.Port() != "" {
+		return parsed.Host, nil
+	}
+	ports := map[string]string{"socks5": "1080", "socks5h": "1080", "http": "80", "https": "443"}
+	port, ok := ports[parsed.Scheme]
+	if !ok {
+		return "", fmt.Errorf("unsupported proxy scheme %v", parsed.Scheme)
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+// OIDCFor returns the OIDC rules of env, which are those configured for the environment if any, or the global ones
+// with the issuer of env as issuer URL
+func (c *Config) OIDCFor(env string) OIDCConfig {
+	rules := c.Env(env).OIDC
+	switch {
+	case !rules.configured() && !c.OIDC.configured():
+		return rules
+	case !rules.configured():
+		rules = c.OIDC
+		rules.IssuerURL = ""
+	}
+	if e, ok := LookupEnvironment(env); ok && rules.IssuerURL == "" {
+		rules.IssuerURL = e.Issuer.Name
+	}
+	return rules
+}
+
+// configured returns true if any of the rules are set
+func (o OIDCConfig) configured() bool {
+	return o.IssuerURL != "" || o.ClientID != "" || o.UsernameClaim != "" || o.UsernamePrefix != "" ||
+		o.GroupsClaim != "" || o.GroupsPrefix != "" || len(o.RequiredClaims) > 0
+}
+
+// ClaimMappingFor returns the claim mapping of tokens issued by issuer, or the default mapping if none is configured
+func (c *Config) ClaimMappingFor(issuer string) ClaimMapping {
+	if mapping, ok := c.Claims[issuer]; ok && mapping != nil {
+		return *mapping
+	}
+	return ClaimMapping{}
+}
+
+// TeamNamespaceFor returns the pattern of team namespaces in env, "{name}" if not configured
+func (c *Config) TeamNamespaceFor(env string) string {
+	if pattern := c.Env(env).TeamNamespace; pattern != "" {
+		return pattern
+	}
+	if c.TeamNamespace != "" {
+		return c.TeamNamespace
+	}
+	return "{name}"
+}
+
+// TeamNamespace returns the namespace of team given pattern, where {team} is replaced by the name of the team, like
+// team-cool-runners, and {name} by the name of the team without the team- prefix, like cool-runners
+func TeamNamespace(pattern, team string) string {
+	return strings.NewReplacer("{team}", team, "{name}", strings.TrimPrefix(team, "team-")).Replace(pattern)
+}
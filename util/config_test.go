@@ -0,0 +1,72 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProxyAddress(t *testing.T) {
+	tests := map[string]string{
+		"socks5://localhost:8888":   "localhost:8888",
+		"socks5://bastion":          "bastion:1080",
+		"http://proxy.example.com":  "proxy.example.com:80",
+		"https://proxy.example.com": "proxy.example.com:443",
+		"ftp://proxy.example.com":   "",
+		"localhost:8888":            "",
+	}
+	for proxyURL, expected := range tests {
+		address, err := ProxyAddress(proxyURL)
+		if address != expected || (expected == "") != (err != nil) {
+			t.Errorf("Expected %v to give address %q, got %q (%v)", proxyURL, expected, address, err)
+		}
+	}
+}
+
+func TestIdPRequestsUseProxyOfEnvironmentUnlessHTTPProxySet(t *testing.T) {
+	config := &Config{Environments: map[string]*EnvConfig{
+		"qa":   {ProxyURL: "socks5://localhost:1080"},
+		"prod": {ProxyURL: "socks5://localhost:1080", HTTP: HTTPConfig{Proxy: "http://proxy:3128"}},
+	}}
+	if proxy := config.HTTPFor("qa").Proxy; proxy != "socks5://localhost:1080" {
+		t.Errorf("Expected proxyURL of qa to be used, got %v", proxy)
+	}
+	if proxy := config.HTTPFor("prod").Proxy; proxy != "http://proxy:3128" {
+		t.Errorf("Expected HTTP proxy of prod to be used, got %v", proxy)
+	}
+}
+
+func TestOIDCForChecksAgainstIssuerOfEnvironment(t *testing.T) {
+	config := &Config{OIDC: OIDCConfig{IssuerURL: "https://login.bisnode.com", GroupsPrefix: "oidc:"},
+		Environments: map[string]*EnvConfig{
+			"qa":    {OIDC: OIDCConfig{GroupsPrefix: "qa:"}},
+			"stage": {OIDC: OIDCConfig{IssuerURL: "https://stage.example.com"}},
+		}}
+	tests := map[string]OIDCConfig{
+		"dev":   {IssuerURL: "https://dev-login.bisnode.com", GroupsPrefix: "oidc:"},
+		"prod":  {IssuerURL: "https://login.bisnode.com", GroupsPrefix: "oidc:"},
+		"qa":    {IssuerURL: "https://qa-login.bisnode.com", GroupsPrefix: "qa:"},
+		"stage": {IssuerURL: "https://stage.example.com"},
+	}
+	for env, expected := range tests {
+		if rules := config.OIDCFor(env); !reflect.DeepEqual(rules, expected) {
+			t.Errorf("Expected OIDC rules of %v to be %+v, got %+v", env, expected, rules)
+		}
+	}
+	if rules := (&Config{}).OIDCFor("dev"); rules.configured() {
+		t.Errorf("Expected no OIDC rules unless configured, got %+v", rules)
+	}
+}
+
+func TestTeamNamespace(t *testing.T) {
+	config := &Config{TeamNamespace: "{team}",
+		Environments: map[string]*EnvConfig{"lab": {TeamNamespace: "lab-{name}"}}}
+	tests := map[string]string{"dev": "team-cool-runners", "lab": "lab-cool-runners"}
+	for env, expected := range tests {
+		if namespace := TeamNamespace(config.TeamNamespaceFor(env), "team-cool-runners"); namespace != expected {
+			t.Errorf("Expected namespace %v in %v, got %v", expected, env, namespace)
+		}
+	}
+	if pattern := (&Config{}).TeamNamespaceFor("dev"); pattern != "{name}" {
+		t.Errorf("Expected default pattern, got %v", pattern)
+	}
+}
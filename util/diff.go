@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff returns a unified diff of the lines of before and after, with the given number of context lines around each
+// change, or an empty string if they are identical
+func Diff(before, after string, context int) string {
+	a, b := splitLines(before), splitLines(after)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op         byte
+		text       string
+		aNum, bNum int
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		// Find next change, and extend the hunk until there are more than 2*context unchanged lines in a row
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for k := first; k < len(lines) && k-last <= 2*context; k++ {
+			if lines[k].op != ' ' {
+				last = k
+			}
+		}
+		from, to := first-context, last+context+1
+		if from < start {
+			from = start
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		aCount, bCount := 0, 0
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		_, _ = fmt.Fprintf(&out, "@@ -%v,%v +%v,%v @@\n", lines[from].aNum+1, aCount, lines[from].bNum+1, bCount)
+		for _, l := range lines[from:to] {
+			_, _ = fmt.Fprintf(&out, "%c%v\n", l.op, l.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
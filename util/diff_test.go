@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestDiffShowsChangedLinesWithContext(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\n"
+	after := "a\nb\nc\nD\ne\nf\ng\nh\n"
+
+	expected := "@@ -3,3 +3,3 @@\n c\n-d\n+D\n e\n@@ -7,1 +7,2 @@\n g\n+h\n"
+	if diff := Diff(before, after, 1); diff != expected {
+		t.Errorf("Expected diff\n%v\nbut was\n%v", expected, diff)
+	}
+
+	expected = "@@ -2,6 +2,7 @@\n b\n c\n-d\n+D\n e\n f\n g\n+h\n"
+	if diff := Diff(before, after, 2); diff != expected {
+		t.Errorf("Expected diff\n%v\nbut was\n%v", expected, diff)
+	}
+}
+
+func TestDiffOfIdenticalInputIsEmpty(t *testing.T) {
+	if diff := Diff("a\nb\n", "a\nb\n", 3); diff != "" {
+		t.Errorf("Expected empty diff, got %v", diff)
+	}
+}
@@ -2,11 +2,11 @@ package util
 
 import (
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,10 +21,12 @@ type Issuer struct {
 	AuthorizeEndpoint string
 }
 
-// IdentityClaims - token claims of interest for our use case
+// IdentityClaims - token claims of interest for our use case, see ParseIdentityClaims
 type IdentityClaims struct {
 	Username string    `json:"email"`
 	Groups   *[]string `json:"groups"`
+	// Mapping is the claim mapping used to find username and groups, and used by ExtractTeams
+	Mapping ClaimMapping `json:"-"`
 	jwt.StandardClaims
 }
 
@@ -41,20 +43,66 @@ const ExecCredentialObject = `{
 
 var (
 	configDir = filepath.Join(clientcmd.RecommendedConfigDir, "kubectl-login")
+
+	environments = map[string]*Environment{
+		"lab": {Name: "lab", Context: "tr.k8s.lab.blue.bisnode.net", Issuer: commonLogin("dev-login.bisnode.com"),
+			CACert: labCaCert},
+		"lab2": {Name: "lab2", Context: "tr2.k8s.lab.blue.bisnode.net", Issuer: commonLogin("dev-login.bisnode.com")},
+		"dev": {Name: "dev", Context: "tr.k8s.dev.blue.bisnode.net", Issuer: commonLogin("dev-login.bisnode.com"),
+			CACert: devCaCert, Default: true},
+		"qa": {Name: "qa", Context: "tr.k8s.qa.blue.bisnode.net", Issuer: commonLogin("qa-login.bisnode.com"),
+			CACert: qaCaCert},
+		"stage": {Name: "stage", Context: "tr.k8s.stage.blue.bisnode.net",
+			Issuer: commonLogin("stage-login.bisnode.com"), CACert: stageCaCert},
+		"prod": {Name: "prod", Context: "tr.k8s.prod.orange.bisnode.net", Issuer: commonLogin("login.bisnode.com"),
+			CACert: prodCaCert},
+	}
 )
 
-// ExtractTeams returns all teams from groups as found in ID token
+// Environment is a cluster that kubectl login knows how to initialize and authenticate for
+type Environment struct {
+	Name string
+	// Context is the name of the context, cluster and user of the environment in kubeconf
+	Context string
+	// Server is the URL of the API server, https://api.${context} if empty
+	Server string
+	// Issuer of tokens for the environment
+	Issuer Issuer
+	// CACert is the base64 encoded PEM CA certificate of the cluster, trusted on first use by init if empty
+	CACert string
+	// Default is true for the environment made current-context when initializing several environments
+	Default bool
+}
+
+// commonLogin returns the Common Login issuer at host
+func commonLogin(host string) Issuer {
+	return Issuer{Name: "https://" + host, AuthorizeEndpoint: "https://" + host + "/as/authorization.oauth2"}
+}
+
+// ServerURL returns the URL of the API server of the environment
+func (e *Environment) ServerURL() string {
+	if e.Server != "" {
+		return e.Server
+	}
+	return "https://api." + e.Context
+}
+
+// ConfigDir returns the directory where kubectl-login keeps its configuration and tokens
+func ConfigDir() string {
+	return configDir
+}
+
+// ExtractTeams returns all teams from groups as found in ID token, according to the claim mapping of claims
 func ExtractTeams(claims *IdentityClaims) (teams []string) {
 	if claims.Groups == nil {
-		log.Print("Claim \"groups\" missing from ID token")
+		log.Printf("Claim %q missing from ID token", claims.Mapping.groupsClaim())
 		return make([]string, 0)
 	}
 
-	for _, g := range *claims.Groups {
-		group := strings.ToLower(g)
-		if strings.HasPrefix(group, "sec-tbac-team-") {
-			teams = append(teams, strings.TrimPrefix(group, "sec-tbac-"))
-		}
+	teams, err := claims.Mapping.Teams(*claims.Groups)
+	if err != nil {
+		log.Printf("Failed extracting teams: %v", err)
+		return make([]string, 0)
 	}
 	return teams
 }
@@ -71,17 +119,14 @@ func Whoami(user string, groups []string, teams []string) string {
 	return output
 }
 
-// JwtToIdentityClaims retrieves user info (name and group belongings) from stored token
-func JwtToIdentityClaims(rawToken string) *IdentityClaims {
-	parser := &jwt.Parser{}
-	claims := &IdentityClaims{}
-
-	_, _, err := parser.ParseUnverified(rawToken, claims)
+// JwtToIdentityClaims retrieves user info (name and group belongings) from stored token, using the default claim
+// mapping. The token itself is never part of the error, as it's a credential.
+func JwtToIdentityClaims(rawToken string) (*IdentityClaims, error) {
+	claims, err := ParseIdentityClaims(rawToken, nil)
 	if err != nil {
-		log.Fatalf("Failed parsing token: %v, error: %v", rawToken, err)
+		return nil, fmt.Errorf("failed parsing token: %w", err)
 	}
-
-	return claims
+	return claims, nil
 }
 
 // RandomString returns a semi-random string of variable length
@@ -95,176 +140,278 @@ func RandomString(length int) string {
 	return b.String()
 }
 
-// ClusterIssuer provides relevant issuer details given a context
-func ClusterIssuer(context string) Issuer {
-	clusterIssuers := map[string]Issuer{
-		"tr.k8s.lab.blue.bisnode.net": {
-			Name:              "https://dev-login.bisnode.com",
-			AuthorizeEndpoint: "https://dev-login.bisnode.com/as/authorization.oauth2",
-		},
-		"tr.k8s.dev.blue.bisnode.net": {
-			Name:              "https://dev-login.bisnode.com",
-			AuthorizeEndpoint: "https://dev-login.bisnode.com/as/authorization.oauth2",
-		},
-		"tr.k8s.qa.blue.bisnode.net": {
-			Name:              "https://qa-login.bisnode.com",
-			AuthorizeEndpoint: "https://qa-login.bisnode.com/as/authorization.oauth2",
-		},
-		"tr.k8s.stage.blue.bisnode.net": {
-			Name:              "https://stage-login.bisnode.com",
-			AuthorizeEndpoint: "https://stage-login.bisnode.com/as/authorization.oauth2",
-		},
-		"tr.k8s.prod.orange.bisnode.net": {
-			Name:              "https://login.bisnode.com",
-			AuthorizeEndpoint: "https://login.bisnode.com/as/authorization.oauth2",
-		},
+// ClusterIssuer provides relevant issuer details given a context of a known environment
+func ClusterIssuer(context string) (Issuer, error) {
+	if e, ok := environmentOf(context); ok {
+		return e.Issuer, nil
 	}
-	if val, ok := clusterIssuers[context]; ok {
-		return val
+	return Issuer{}, fmt.Errorf("no issuer known for context %v, as it's not that of an environment (%v)", context,
+		strings.Join(KnownEnvironments(), "|"))
+}
+
+// environmentOf returns the known environment whose context is, or is the base of, context
+func environmentOf(context string) (*Environment, bool) {
+	for _, e := range environments {
+		if e.Context == BaseContext(context) {
+			return e, true
+		}
 	}
-	return clusterIssuers["tr.k8s.dev.blue.bisnode.net"]
+	return nil, false
 }
 
 // ContextToEnv translates any known context to it's corresponding environment, or dev if not found
 func ContextToEnv(context string) (env string) {
-	ctxEnvMap := map[string]string{
-		"tr.k8s.lab.blue.bisnode.net":    "lab",
-		"tr2.k8s.lab.blue.bisnode.net":   "lab2",
-		"tr.k8s.dev.blue.bisnode.net":    "dev",
-		"tr.k8s.qa.blue.bisnode.net":     "qa",
-		"tr.k8s.stage.blue.bisnode.net":  "stage",
-		"tr.k8s.prod.orange.bisnode.net": "prod",
-	}
-	if val, ok := ctxEnvMap[context]; ok {
-		return val
+	if e, ok := environmentOf(context); ok {
+		return e.Name
 	}
-	log.Printf("Can't translate context '%v' to env (dev|qa|stage|prod), defaulting to 'dev'", context)
+	log.Printf("Can't translate context '%v' to env (%v), defaulting to 'dev'", context,
+		strings.Join(KnownEnvironments(), "|"))
 	return "dev"
 }
 
+// IsKnownContext returns true if context is, or derives from, the context of a known environment
+func IsKnownContext(context string) bool {
+	_, ok := environmentOf(context)
+	return ok
+}
+
+// BaseContext returns the context that context derives from, like tr.k8s.dev.blue.bisnode.net for the team context
+// tr.k8s.dev.blue.bisnode.net/team-cool-runners, or context itself if not derived from another
+func BaseContext(context string) string {
+	if base, _, derived := strings.Cut(context, "/"); derived {
+		return base
+	}
+	return context
+}
+
+// KnownEnvironments returns the names of all known environments, sorted
+func KnownEnvironments() []string {
+	envs := make([]string, 0, len(environments))
+	for env := range environments {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// EnvToContext translates env to the context of its cluster, or returns env itself if not a known environment
+func EnvToContext(env string) string {
+	if e, ok := environments[env]; ok {
+		return e.Context
+	}
+	return env
+}
+
+// LookupEnvironment returns the known environment named env
+func LookupEnvironment(env string) (*Environment, bool) {
+	e, ok := environments[env]
+	return e, ok
+}
+
+// ConfigureEnvironments adds the environments of the configuration to the known environments, or overrides the settings
+// of known ones. The context of a new environment defaults to tr.k8s.${env}.${account}.bisnode.net. If any environment
+// is configured as default, it replaces the default environment. New environments must have an issuer, as tokens for
+// them would otherwise be issued by the wrong identity provider. Those that haven't are left out, and returned in the
+// error.
+func ConfigureEnvironments(configured map[string]*EnvConfig) error {
+	hasDefault := false
+	var refused []string
+	for name, conf := range configured {
+		if conf == nil {
+			continue
+		}
+		e, ok := environments[name]
+		if !ok && conf.Issuer == "" {
+			refused = append(refused, name)
+			continue
+		}
+		if !ok {
+			e = &Environment{Name: name}
+			environments[name] = e
+		}
+		switch {
+		case conf.Context != "":
+			e.Context = conf.Context
+		case conf.Account != "" || e.Context == "":
+			account := conf.Account
+			if account == "" {
+				account = "blue"
+			}
+			e.Context = fmt.Sprintf("tr.k8s.%v.%v.bisnode.net", name, account)
+		}
+		if conf.Server != "" {
+			e.Server = conf.Server
+		}
+		if conf.Issuer != "" {
+			e.Issuer = Issuer{Name: conf.Issuer, AuthorizeEndpoint: conf.AuthorizeEndpoint}
+			if e.Issuer.AuthorizeEndpoint == "" {
+				e.Issuer.AuthorizeEndpoint = strings.TrimSuffix(conf.Issuer, "/") + "/as/authorization.oauth2"
+			}
+		}
+		hasDefault = hasDefault || conf.Default
+	}
+	if hasDefault {
+		for name, e := range environments {
+			e.Default = configured[name] != nil && configured[name].Default
+		}
+	}
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return fmt.Errorf("environments %v left out, as they have no issuer configured",
+			strings.Join(refused, ", "))
+	}
+	return nil
+}
+
 // LoadConfigFromContext loads config object for provided context
 func LoadConfigFromContext(context string) *api.Config {
-	file := clientcmd.RecommendedHomeFile + "." + ContextToEnv(context)
-	conf, err := clientcmd.LoadFromFile(file)
+	conf, err := ReadConfigFromContext(context)
 	if err != nil {
-		log.Fatalf("Failed reading file %v", file)
+		log.Fatal(err)
 	}
 	return conf
 }
 
-// Join with both prefix and suffix
-func Join(items []string, prefix, suffix string) string {
-	if len(items) == 1 {
-		return items[0]
+// ReadConfigFromContext is like LoadConfigFromContext, but returns an error rather than exiting on failure. The context
+// is looked up using the standard client-go loading rules, i.e. in all files of KUBECONFIG or in ~/.kube/config, and
+// then in the ~/.kube/config.${env} file written by kubectl login init.
+func ReadConfigFromContext(context string) (*api.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	conf, err := rules.Load()
+	if err == nil {
+		if _, ok := conf.Contexts[context]; ok {
+			return conf, nil
+		}
 	}
-	joined := ""
-	for _, item := range items {
-		joined += prefix + item + suffix
+
+	file := clientcmd.RecommendedHomeFile + "." + ContextToEnv(context)
+	conf, err = clientcmd.LoadFromFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("context %v not found in %v or %v", context,
+			strings.Join(rules.GetLoadingPrecedence(), string(filepath.ListSeparator)), file)
 	}
-	return joined
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file %v: %w", file, err)
+	}
+	return conf, nil
 }
 
-// WriteToken writes token to ~/.kube/kubectl-login/${env}/token.jwt
-func WriteToken(token string, context string) error {
-	dir := filepath.Join(configDir, ContextToEnv(context))
-	err := os.MkdirAll(dir, os.ModePerm)
-	if err != nil {
-		return err
+// ClusterServer returns the API server URL of context, as configured in kubeconf if found there
+func ClusterServer(context string) string {
+	if cluster := KubeconfCluster(context); cluster != nil && cluster.Server != "" {
+		return cluster.Server
+	}
+	if e, ok := environmentOf(context); ok {
+		return e.ServerURL()
 	}
-	err = ioutil.WriteFile(filepath.Join(dir, "token.jwt"), []byte(token), 0644)
+	return "https://api." + context
+}
+
+// KubeconfCluster returns the kubeconf cluster of context, or nil if not found
+func KubeconfCluster(context string) *api.Cluster {
+	conf, err := ReadConfigFromContext(context)
 	if err != nil {
-		return err
+		return nil
+	}
+	if contextConf, ok := conf.Contexts[context]; ok {
+		return conf.Clusters[contextConf.Cluster]
 	}
 	return nil
 }
 
-// ReadToken returns token or empty string if missing or failure to read it (likely due to it not being written yet)
-func ReadToken(context string) string {
-	dir := filepath.Join(configDir, ContextToEnv(context))
-	bytes, err := ioutil.ReadFile(filepath.Join(dir, "token.jwt"))
-	if err != nil {
-		return ""
+// Join with both prefix and suffix
+func Join(items []string, prefix, suffix string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	joined := ""
+	for _, item := range items {
+		joined += prefix + item + suffix
 	}
-	return string(bytes)
+	return joined
 }
 
-// ClusterCaCert provides the CA cert for the given cluster, or "unknown" if not in map of known clusters
+// ClusterCaCert provides the CA cert of the environment of context, or "unknown" if the environment has none, or
+// context is not that of a known environment
 func ClusterCaCert(context string) string {
-	//goland:noinspection SpellCheckingInspection
-	clusterCaCerts := map[string]string{
-		"tr.k8s.lab.blue.bisnode.net": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRjR6RmdFcFdQV" +
-			"WlwVlhjck1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1qTXhNREE0TVRRek1UV" +
-			"TBXaGNOTXpNeE1EQTNNVFF6TVRVMApXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFR" +
-			"kFBT0NBUThBCk1JSUJDZ0tDQVFFQXJ0MkhJTURySFo1elBzSUd4L0ZvcEtVQjh4ZEhTQ2RiTkNwNWZOVWJoRFdld3ZuZGJ5TXgKMko3K" +
-			"0ovaWJXcU5zQUllK29ETk85OFNGdlJzejVOTEdKbkVjTkp4d2hPSTBOaTV1a2JLY29sZ2ZKOHBHeTlwagphdEcxTkM2UGpoOXFzdG91W" +
-			"jlaOVk3SllOZUlmTWpuRWVNOW5JNmcvRjgxb20wTTk3RHNkYU13Nkk3aGhNVEVuCnZHa0txVTB4RXpEYTVXeDZabDY0RENPWlYvbkNnR" +
-			"WRHOERHMEZmeUVXcjhWZ2tKbDVqSGZBU1Y2QXZQbTNQeW4KRUZZV1BsZVJ3Q0VnTE8yMkNTWWEyTUNzU1owVWh3OU9nTjdwcEdnU1J1S" +
-			"XBoUE9KeFhQWE1DQ0FEemhGWkNFTwpGcklYREF1bHRpY3pQKzBIWHVUd2ZGNnhWUHpTRExoMDhRSURBUUFCb3lNd0lUQU9CZ05WSFE4Q" +
-			"kFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFtZFhhTkYxN2pRR3MKZFlWL" +
-			"1IydnRaRXhoanVVbmVCY0M1dWJaMVZwNzV4MkdwNDY3V3ZQSWlGSG54c2twN3M1MG1Ca3BESkhMbFczNApBQXpDUG1hV2U3MVduV3BHU" +
-			"U9JT0ZCcXpSWUVvcnIzVndSQm9CcnNzZVFPWk1kZVNuWEMrVjlFTFRRQTBGN1NCCndyN0ZnTzR0MllWbGI3N05aMTQvc0REeWkxTEh2S" +
-			"m9YNGc1dG9uMkJkNVRNTTdVdWtiTDY2ZS9KamRveUdjV3MKeDJNRjZDTzBuRG1PMWc5YUpXaDh6L1EwRzR2TyswZGI4UUd1KzlxT05BY" +
-			"2FhU3NWZEtaUTVEd0ZJV3MrbGtlNgpzbkVlTzd0T1ROOGRNSmtkV0w2WTE2ZkVGVWV1dTJHSk52NmNXajd6UkV5ZXduT1JpOHFpNWo5T" +
-			"UlzcW1xTTlMClVtS2cxckdxTHc9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg==",
-		"tr.k8s.dev.blue.bisnode.net": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRjFWN1BFL092R" +
-			"EpHNERrTE1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1qTXdOREV4TVRFek1qR" +
-			"TBXaGNOTXpNd05ERXdNVEV6TWpFMApXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFR" +
-			"kFBT0NBUThBCk1JSUJDZ0tDQVFFQW5zV3Y5WmgveTJpMEpyTjdZS2V6K2xzZWJpN0hGZkxSWUx5di9mbkdxTDR0eGFhUXRkamEKc0hae" +
-			"S9zVVRWVitwajdsMUZxQVRDSFo0ZjIyZGcySHVYVEU1YWJ0azQzZE9Rc2FtaUo2aDNRMlJCbEp2ZVU3LwpHZ0hYcXI2SHFRNHFIdnl6Q" +
-			"VZ4Wk1ZMmk5MTA5K0R0ejh1TmVRZWtteHgrZjcvMnIzL3lFV2FEQ1ZWSHlOTnd6CmRYalZGUmpIVzFlZTFzbTM0YjExcDRIN0F5WDZZc" +
-			"jN2dlFQQVpqNHhQMm1HTVlxVFAwRzRnL2JaL2QzZ2JXWDIKTmlObXpRLzMzYlgrTmQ3NjUwdE82NXU5dFF1alA2dGZHYjN1d1ZKYUVXR" +
-			"UtPZWFkUEtMZlR6MG42T1pEbWVTSQo2Y1ljRWw1V2wxYWFnb3c1UExWSTROTkNtZHJpSWpkOWR3SURBUUFCb3lNd0lUQU9CZ05WSFE4Q" +
-			"kFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFaZElDb01Qc3VwY0UKQnZYd" +
-			"GhxbS9uY3VSVmlKRXVlTFZPcHBsMmw0emhITjd1aS9nMkE3STJzVjF6alhzSXZlZ0JDZE9EaEhVaU5OUAo1dVZ2M3BLRkRJWmFaM0I3L" +
-			"0FiZW1rOTByN01Wc1FxWi9pRGRURmV6dFowU1F3U0ppODlaY2RkdC9oTjFYdVFaCmxWSlMvMDRGUzBZYzJYOUFic28vRG1ObkloTUlqb" +
-			"TNyejlLZkNmNUFpMzFVTnF4cGZKekM0eU9IbjVUdkg4dE0KWVhGbm4rL3RoWjJtZTVyYytOM2F1b3hUQ0w4RksrZTQzY3A0RXU2enJkd" +
-			"W5EWUg1U3NKejlNeHlTclNsOVYwVQptZWIwdUxXT0xUclZzczBvNSs5Wkk3Q2J1Z3cwalpqTENRNHRoWkRuSWlIalV2WkVtWEl4SkdZU" +
-			"zA3UERGUXhrCm1SUmxuVFVhWlE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg==",
-		"tr.k8s.qa.blue.bisnode.net": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZwVXRIVjk1Z0" +
-			"VXSjJybk1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF5TURZME1qTT" +
-			"JXaGNOTWpneE1EQXhNRFkwTWpNMgpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFRk" +
-			"FBT0NBUThBCk1JSUJDZ0tDQVFFQTIrZTdlTlVhWHlsek92QzgvTnZGVGVROFo1aVNFS2p6V3owMnB4d2YySVluR2Y3byt6SEIKMFh0cz" +
-			"VORFFiZTdzUGdsUFJ2eDBnZWNuTGdRWVhWc1poZVludG9jb3g5RDlXQnQ1aXIvM3RZcEVOOGxiUzRkSwpBdWRPdmlqM05vblpDWW4wNG" +
-			"FhanVsRUFxOVREaHdRNEdUbUpiTTFFRUJiTEUrVjVNenV0REF6Y0x3aTJOeVFlCmVEa3dmK2pWemFiOWNyUy8wTDdOcVpLME1YUWUvTG" +
-			"NKVm5zbHNCQ1FZKzVvdC9yeHNNVVh6RTlCaGhoN3k1b3EKT0FEbHZFUzFueFNuZnIvOURlSytDcDZUUmZFUGhYTnM3dzJrOVVGYzd3c2" +
-			"l0dVZ0RTRGSDY3Nzh5RFJ0a29wKwowMFIrL25jWW1vVTBWSlVrVS9XZzNzelBxazdtOFV0cklRSURBUUFCb3lNd0lUQU9CZ05WSFE4Qk" +
-			"FmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFSaThWQ09Vanord2kKWEFLTF" +
-			"lsMVZlMWdwZWpqc3RTZ3JUSEZ6aWpvNmFyeGNIbW1DaWFCQWU5dkVkaFZrbWt0N0x0aU1Dd2xNdFpUcQpPdGFlNEZzT25PREJHeG5HT2" +
-			"l0NzkxSnBhZ2lzZ2NFTFpHbEIvNlArSHkybVdPcEZ2L29aNGdxTWNJVzdNSDZzCmg2cjhUNEtLNmIwSWFuaFR6SlhSZEJtY3pWRGNKdH" +
-			"pVRmpwUERTZ1VFaHlTL0RVZzhnTjV3dEp4SEFsTEtoWjgKYVA0K1pUQnRMc2JpN0FLeWt4T3FaQmFMa0JGMjRScitTM3lXcVJLd0dDRn" +
-			"hxaHNHQi90N2RBQkNBU3ZUSnlDLwpFMU5oRUQyL3VSdklpWUUwUmloR1EwWGo0N2NscGhWcGtxYTg1SFo2aTdDbFpoN3hHOFZsTGhYdE" +
-			"5BRTdTNjBUCmt0VFVEUVJ3SWc9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg==",
-		"tr.k8s.stage.blue.bisnode.net": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZxb2s4K1J" +
-			"ZSExRUklWUU1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF6TURneE9" +
-			"UTTFXaGNOTWpneE1EQXlNRGd4T1RNMQpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVF" +
-			"FRkFBT0NBUThBCk1JSUJDZ0tDQVFFQXJEQmZhVmRzbys3Qk9EOTk5SlFGN081SDBObTRBMEp2dUxLVzBDNTN6Q05Zd1ljalFkaE4KdFg" +
-			"wQlJpbmxQSjBmSmF5VEtEYml2VmZmbzhLRTcycVZUbExCZUpkSUwxVGNITkZKcEpMak1rUzJVM0hjUThSVQpMeUU2aHNxNVR1Zkk4MTR" +
-			"sMGpWL2VSSHBZa3FqcXkrRkNDU2dKY2s2VGkrVHNIanRMUHBGUmE2cXJMSHp0RHFWCjdZM28xblFTYU9NK3BKYjc2eU1ya0NYNHQ0R1R" +
-			"6NlVGaUJIT2xrVDk2V1RsU3Y3VzBBUUFwc3Z4VnR1SmlyY1AKeXlGNVdxTkVrSXpTbEV0SjRzMGJuWUxQcy9SRjl4ZzNzYThyOEs2TWp" +
-			"yTFhvNlBVajcyZFFvb2tIRFBMVVJZSQpoWnYyTGlQdG1LOEJCRi9BZ1dqTlpEVGFpMDZRQTd5ZjlRSURBUUFCb3lNd0lUQU9CZ05WSFE" +
-			"4QkFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFBYkNyd1lXcVBDd1IKcFl" +
-			"1eXB3VW0yWWpXOW1aK21PM083Z0wzK1F2eG1PYmVqU0tiQUlaV3pNNG14ZTZVMDdMekZ3TDdnOEpIU0YxeApYakRQRUJHOGdQSmlDelB" +
-			"4SnpyZ1U0NFBUMjYwZmZMSkF3RlV1K1lCd1Juc2NNZ1J5NWR2UEN2cjlBeVhIWG01CndEdzhoUWl2bGt2ZFVBSm5YUXU2YWxJT1BvVXF" +
-			"zanIyMGpZL29DVGI3Sm1oMStIeER0WmRFU2wvWmZSb3lmUEgKc3BZV1RoSzkxZEJkdFg1QTRrclZKaDNFQW1ZaGhQVkxNcDZyVTdOUG9" +
-			"JTVNtQ2VoVHJyY05XaEU4ZjBwVFVTOQp4ZW1jUnQwVUxwSWlRQ3kxK1Yya25tYmRxYmJxUVlhUGZ6NXpZc3hmdTVSdU1zeWQxUWNvMDR" +
-			"0UU5XWnhFQjF5Ckcxd0JNSUgvZFE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg==",
-		"tr.k8s.prod.orange.bisnode.net": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZxMnlKeT" +
-			"kyOEF6cnlMS01BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF6TVRJek" +
-			"9UVTFXaGNOTWpneE1EQXlNVEl6T1RVMQpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQV" +
-			"FFRkFBT0NBUThBCk1JSUJDZ0tDQVFFQXlGYUlTaWl5ekdBWmFkZXhMelZzdjNwZ2hRQmh4TUtyd0RNaTZ5WkNqY0JHWkF1NEVRWXgKa0" +
-			"pyV2ZPaHhXUUM4Z3dEckZCTTF3dUtoRlVESDFOOXIzQm04TDN5N3R5QjM3aEx3ZG5hK09JYjlKWDk5a3N5bwpINEpmOWk3Ukh1UzFwYT" +
-			"VUaXpuR05IL2xNRU90dGpDTTlIb0pYcWpSZ2NjS1B4SCs2RUczaU1jUHdlWTU2L1pYCkFZOTNxdnlMSXE1bTlUVVZlM3NxS3FYTTBUaW" +
-			"9ZNmxNdmVaY0dYVXVFd3N4dkJZU3RDRTM2N2FYd3J4aWRORnQKUGpYQlF3YklHcGg3MUF1RmMzMHE1SnFxTmVkYTdEcXNSbzJYUXpDTn" +
-			"Z3c1U3R2lTSDNKbGc0YmlRRDZKelQwLwpYa2hrbDQ3b0tJRXovV0I1bmhSNVZLUG9zWkVvYVd1NFFRSURBUUFCb3lNd0lUQU9CZ05WSF" +
-			"E4QkFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUF2dFB0OE5vNGtXbVkKeX" +
-			"JqRDdqVndnRDc1SDJQR1Q0WjltbG9TV05NVEdsc3lIMFE2TXBXVHFlaTdyQkQ1TFZ0Vzh0dEdWNFVFd25PRwpXeVJMMFMvZHRBY3J1Uk" +
-			"xXYnJWaUEvUU5kN1BHZ2dlQXRJSkZBQk11QStGaG1qV1A5cmVocnBmYVZMWjU5NDNiCndhUWg5Ky9FV2czdEE2VTgwREZzMGsra0U0WD" +
-			"JTcWdaVUlMRk9GVXJjdWFKR1FQNUhaQ0JQMGlzQkJtbFNCeDcKRGIvRllVZUlVemRrWjdXZ0RCbDcwd3ByM0Z4NEJmb1daUHRPWG9oSn" +
-			"FUOWtuZU85eS9ZdVYzUlArMTVSYUNudwpNYmpxQTdveFliZ2hMSHdLM1BmYlhkR2RZbkhZNldHR3paZWY0b2hTNlBPeUJaanN0c01RSD" +
-			"RHMFJhcjZGRkFQCmtMVXdGeWtwbUE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg==",
-	}
-	if val, ok := clusterCaCerts[context]; ok {
-		return val
+	if e, ok := environmentOf(context); ok && e.CACert != "" {
+		return e.CACert
 	}
 	return "unknown"
 }
+
+// CA certificates of the clusters of the built-in environments, base64 encoded PEM
+//
+//goland:noinspection SpellCheckingInspection
+const (
+	labCaCert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRjR6RmdFcFdQV" +
+		"WlwVlhjck1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1qTXhNREE0TVRRek1UV" +
+		"TBXaGNOTXpNeE1EQTNNVFF6TVRVMApXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFR" +
+		"kFBT0NBUThBCk1JSUJDZ0tDQVFFQXJ0MkhJTURySFo1elBzSUd4L0ZvcEtVQjh4ZEhTQ2RiTkNwNWZOVWJoRFdld3ZuZGJ5TXgKMko3K" +
+		"0ovaWJXcU5zQUllK29ETk85OFNGdlJzejVOTEdKbkVjTkp4d2hPSTBOaTV1a2JLY29sZ2ZKOHBHeTlwagphdEcxTkM2UGpoOXFzdG91W" +
+		"jlaOVk3SllOZUlmTWpuRWVNOW5JNmcvRjgxb20wTTk3RHNkYU13Nkk3aGhNVEVuCnZHa0txVTB4RXpEYTVXeDZabDY0RENPWlYvbkNnR" +
+		"WRHOERHMEZmeUVXcjhWZ2tKbDVqSGZBU1Y2QXZQbTNQeW4KRUZZV1BsZVJ3Q0VnTE8yMkNTWWEyTUNzU1owVWh3OU9nTjdwcEdnU1J1S" +
+		"XBoUE9KeFhQWE1DQ0FEemhGWkNFTwpGcklYREF1bHRpY3pQKzBIWHVUd2ZGNnhWUHpTRExoMDhRSURBUUFCb3lNd0lUQU9CZ05WSFE4Q" +
+		"kFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFtZFhhTkYxN2pRR3MKZFlWL" +
+		"1IydnRaRXhoanVVbmVCY0M1dWJaMVZwNzV4MkdwNDY3V3ZQSWlGSG54c2twN3M1MG1Ca3BESkhMbFczNApBQXpDUG1hV2U3MVduV3BHU" +
+		"U9JT0ZCcXpSWUVvcnIzVndSQm9CcnNzZVFPWk1kZVNuWEMrVjlFTFRRQTBGN1NCCndyN0ZnTzR0MllWbGI3N05aMTQvc0REeWkxTEh2S" +
+		"m9YNGc1dG9uMkJkNVRNTTdVdWtiTDY2ZS9KamRveUdjV3MKeDJNRjZDTzBuRG1PMWc5YUpXaDh6L1EwRzR2TyswZGI4UUd1KzlxT05BY" +
+		"2FhU3NWZEtaUTVEd0ZJV3MrbGtlNgpzbkVlTzd0T1ROOGRNSmtkV0w2WTE2ZkVGVWV1dTJHSk52NmNXajd6UkV5ZXduT1JpOHFpNWo5T" +
+		"UlzcW1xTTlMClVtS2cxckdxTHc9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+	devCaCert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRjFWN1BFL092R" +
+		"EpHNERrTE1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1qTXdOREV4TVRFek1qR" +
+		"TBXaGNOTXpNd05ERXdNVEV6TWpFMApXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFR" +
+		"kFBT0NBUThBCk1JSUJDZ0tDQVFFQW5zV3Y5WmgveTJpMEpyTjdZS2V6K2xzZWJpN0hGZkxSWUx5di9mbkdxTDR0eGFhUXRkamEKc0hae" +
+		"S9zVVRWVitwajdsMUZxQVRDSFo0ZjIyZGcySHVYVEU1YWJ0azQzZE9Rc2FtaUo2aDNRMlJCbEp2ZVU3LwpHZ0hYcXI2SHFRNHFIdnl6Q" +
+		"VZ4Wk1ZMmk5MTA5K0R0ejh1TmVRZWtteHgrZjcvMnIzL3lFV2FEQ1ZWSHlOTnd6CmRYalZGUmpIVzFlZTFzbTM0YjExcDRIN0F5WDZZc" +
+		"jN2dlFQQVpqNHhQMm1HTVlxVFAwRzRnL2JaL2QzZ2JXWDIKTmlObXpRLzMzYlgrTmQ3NjUwdE82NXU5dFF1alA2dGZHYjN1d1ZKYUVXR" +
+		"UtPZWFkUEtMZlR6MG42T1pEbWVTSQo2Y1ljRWw1V2wxYWFnb3c1UExWSTROTkNtZHJpSWpkOWR3SURBUUFCb3lNd0lUQU9CZ05WSFE4Q" +
+		"kFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFaZElDb01Qc3VwY0UKQnZYd" +
+		"GhxbS9uY3VSVmlKRXVlTFZPcHBsMmw0emhITjd1aS9nMkE3STJzVjF6alhzSXZlZ0JDZE9EaEhVaU5OUAo1dVZ2M3BLRkRJWmFaM0I3L" +
+		"0FiZW1rOTByN01Wc1FxWi9pRGRURmV6dFowU1F3U0ppODlaY2RkdC9oTjFYdVFaCmxWSlMvMDRGUzBZYzJYOUFic28vRG1ObkloTUlqb" +
+		"TNyejlLZkNmNUFpMzFVTnF4cGZKekM0eU9IbjVUdkg4dE0KWVhGbm4rL3RoWjJtZTVyYytOM2F1b3hUQ0w4RksrZTQzY3A0RXU2enJkd" +
+		"W5EWUg1U3NKejlNeHlTclNsOVYwVQptZWIwdUxXT0xUclZzczBvNSs5Wkk3Q2J1Z3cwalpqTENRNHRoWkRuSWlIalV2WkVtWEl4SkdZU" +
+		"zA3UERGUXhrCm1SUmxuVFVhWlE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+	qaCaCert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZwVXRIVjk1Z0" +
+		"VXSjJybk1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF5TURZME1qTT" +
+		"JXaGNOTWpneE1EQXhNRFkwTWpNMgpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVFFRk" +
+		"FBT0NBUThBCk1JSUJDZ0tDQVFFQTIrZTdlTlVhWHlsek92QzgvTnZGVGVROFo1aVNFS2p6V3owMnB4d2YySVluR2Y3byt6SEIKMFh0cz" +
+		"VORFFiZTdzUGdsUFJ2eDBnZWNuTGdRWVhWc1poZVludG9jb3g5RDlXQnQ1aXIvM3RZcEVOOGxiUzRkSwpBdWRPdmlqM05vblpDWW4wNG" +
+		"FhanVsRUFxOVREaHdRNEdUbUpiTTFFRUJiTEUrVjVNenV0REF6Y0x3aTJOeVFlCmVEa3dmK2pWemFiOWNyUy8wTDdOcVpLME1YUWUvTG" +
+		"NKVm5zbHNCQ1FZKzVvdC9yeHNNVVh6RTlCaGhoN3k1b3EKT0FEbHZFUzFueFNuZnIvOURlSytDcDZUUmZFUGhYTnM3dzJrOVVGYzd3c2" +
+		"l0dVZ0RTRGSDY3Nzh5RFJ0a29wKwowMFIrL25jWW1vVTBWSlVrVS9XZzNzelBxazdtOFV0cklRSURBUUFCb3lNd0lUQU9CZ05WSFE4Qk" +
+		"FmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFSaThWQ09Vanord2kKWEFLTF" +
+		"lsMVZlMWdwZWpqc3RTZ3JUSEZ6aWpvNmFyeGNIbW1DaWFCQWU5dkVkaFZrbWt0N0x0aU1Dd2xNdFpUcQpPdGFlNEZzT25PREJHeG5HT2" +
+		"l0NzkxSnBhZ2lzZ2NFTFpHbEIvNlArSHkybVdPcEZ2L29aNGdxTWNJVzdNSDZzCmg2cjhUNEtLNmIwSWFuaFR6SlhSZEJtY3pWRGNKdH" +
+		"pVRmpwUERTZ1VFaHlTL0RVZzhnTjV3dEp4SEFsTEtoWjgKYVA0K1pUQnRMc2JpN0FLeWt4T3FaQmFMa0JGMjRScitTM3lXcVJLd0dDRn" +
+		"hxaHNHQi90N2RBQkNBU3ZUSnlDLwpFMU5oRUQyL3VSdklpWUUwUmloR1EwWGo0N2NscGhWcGtxYTg1SFo2aTdDbFpoN3hHOFZsTGhYdE" +
+		"5BRTdTNjBUCmt0VFVEUVJ3SWc9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+	stageCaCert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZxb2s4K1J" +
+		"ZSExRUklWUU1BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF6TURneE9" +
+		"UTTFXaGNOTWpneE1EQXlNRGd4T1RNMQpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQVF" +
+		"FRkFBT0NBUThBCk1JSUJDZ0tDQVFFQXJEQmZhVmRzbys3Qk9EOTk5SlFGN081SDBObTRBMEp2dUxLVzBDNTN6Q05Zd1ljalFkaE4KdFg" +
+		"wQlJpbmxQSjBmSmF5VEtEYml2VmZmbzhLRTcycVZUbExCZUpkSUwxVGNITkZKcEpMak1rUzJVM0hjUThSVQpMeUU2aHNxNVR1Zkk4MTR" +
+		"sMGpWL2VSSHBZa3FqcXkrRkNDU2dKY2s2VGkrVHNIanRMUHBGUmE2cXJMSHp0RHFWCjdZM28xblFTYU9NK3BKYjc2eU1ya0NYNHQ0R1R" +
+		"6NlVGaUJIT2xrVDk2V1RsU3Y3VzBBUUFwc3Z4VnR1SmlyY1AKeXlGNVdxTkVrSXpTbEV0SjRzMGJuWUxQcy9SRjl4ZzNzYThyOEs2TWp" +
+		"yTFhvNlBVajcyZFFvb2tIRFBMVVJZSQpoWnYyTGlQdG1LOEJCRi9BZ1dqTlpEVGFpMDZRQTd5ZjlRSURBUUFCb3lNd0lUQU9CZ05WSFE" +
+		"4QkFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUFBYkNyd1lXcVBDd1IKcFl" +
+		"1eXB3VW0yWWpXOW1aK21PM083Z0wzK1F2eG1PYmVqU0tiQUlaV3pNNG14ZTZVMDdMekZ3TDdnOEpIU0YxeApYakRQRUJHOGdQSmlDelB" +
+		"4SnpyZ1U0NFBUMjYwZmZMSkF3RlV1K1lCd1Juc2NNZ1J5NWR2UEN2cjlBeVhIWG01CndEdzhoUWl2bGt2ZFVBSm5YUXU2YWxJT1BvVXF" +
+		"zanIyMGpZL29DVGI3Sm1oMStIeER0WmRFU2wvWmZSb3lmUEgKc3BZV1RoSzkxZEJkdFg1QTRrclZKaDNFQW1ZaGhQVkxNcDZyVTdOUG9" +
+		"JTVNtQ2VoVHJyY05XaEU4ZjBwVFVTOQp4ZW1jUnQwVUxwSWlRQ3kxK1Yya25tYmRxYmJxUVlhUGZ6NXpZc3hmdTVSdU1zeWQxUWNvMDR" +
+		"0UU5XWnhFQjF5Ckcxd0JNSUgvZFE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+	prodCaCert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSUMwekNDQWJ1Z0F3SUJBZ0lNRlZxMnlKeT" +
+		"kyOEF6cnlMS01BMEdDU3FHU0liM0RRRUJDd1VBTUJVeEV6QVIKQmdOVkJBTVRDbXQxWW1WeWJtVjBaWE13SGhjTk1UZ3hNREF6TVRJek" +
+		"9UVTFXaGNOTWpneE1EQXlNVEl6T1RVMQpXakFWTVJNd0VRWURWUVFERXdwcmRXSmxjbTVsZEdWek1JSUJJakFOQmdrcWhraUc5dzBCQV" +
+		"FFRkFBT0NBUThBCk1JSUJDZ0tDQVFFQXlGYUlTaWl5ekdBWmFkZXhMelZzdjNwZ2hRQmh4TUtyd0RNaTZ5WkNqY0JHWkF1NEVRWXgKa0" +
+		"pyV2ZPaHhXUUM4Z3dEckZCTTF3dUtoRlVESDFOOXIzQm04TDN5N3R5QjM3aEx3ZG5hK09JYjlKWDk5a3N5bwpINEpmOWk3Ukh1UzFwYT" +
+		"VUaXpuR05IL2xNRU90dGpDTTlIb0pYcWpSZ2NjS1B4SCs2RUczaU1jUHdlWTU2L1pYCkFZOTNxdnlMSXE1bTlUVVZlM3NxS3FYTTBUaW" +
+		"9ZNmxNdmVaY0dYVXVFd3N4dkJZU3RDRTM2N2FYd3J4aWRORnQKUGpYQlF3YklHcGg3MUF1RmMzMHE1SnFxTmVkYTdEcXNSbzJYUXpDTn" +
+		"Z3c1U3R2lTSDNKbGc0YmlRRDZKelQwLwpYa2hrbDQ3b0tJRXovV0I1bmhSNVZLUG9zWkVvYVd1NFFRSURBUUFCb3lNd0lUQU9CZ05WSF" +
+		"E4QkFmOEVCQU1DCkFRWXdEd1lEVlIwVEFRSC9CQVV3QXdFQi96QU5CZ2txaGtpRzl3MEJBUXNGQUFPQ0FRRUF2dFB0OE5vNGtXbVkKeX" +
+		"JqRDdqVndnRDc1SDJQR1Q0WjltbG9TV05NVEdsc3lIMFE2TXBXVHFlaTdyQkQ1TFZ0Vzh0dEdWNFVFd25PRwpXeVJMMFMvZHRBY3J1Uk" +
+		"xXYnJWaUEvUU5kN1BHZ2dlQXRJSkZBQk11QStGaG1qV1A5cmVocnBmYVZMWjU5NDNiCndhUWg5Ky9FV2czdEE2VTgwREZzMGsra0U0WD" +
+		"JTcWdaVUlMRk9GVXJjdWFKR1FQNUhaQ0JQMGlzQkJtbFNCeDcKRGIvRllVZUlVemRrWjdXZ0RCbDcwd3ByM0Z4NEJmb1daUHRPWG9oSn" +
+		"FUOWtuZU85eS9ZdVYzUlArMTVSYUNudwpNYmpxQTdveFliZ2hMSHdLM1BmYlhkR2RZbkhZNldHR3paZWY0b2hTNlBPeUJaanN0c01RSD" +
+		"RHMFJhcjZGRkFQCmtMVXdGeWtwbUE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+)
@@ -2,10 +2,14 @@ package util
 
 import (
 	"log"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang-jwt/jwt"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 func TestExtractsSingleTeamFromListOfGroups(t *testing.T) {
@@ -15,7 +19,10 @@ func TestExtractsSingleTeamFromListOfGroups(t *testing.T) {
 		"team-also-ignored",
 		"definitely-ignored",
 	})
-	claims := JwtToIdentityClaims(token)
+	claims, err := JwtToIdentityClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
 	teams := ExtractTeams(claims)
 
 	expected := []string{"team-cool-runners"}
@@ -33,7 +40,10 @@ func TestExtractsMultipleTeamsFromListOfGroups(t *testing.T) {
 		"sec-tbac-team-lunatics",
 		"definitely-not-gonna-count",
 	})
-	claims := JwtToIdentityClaims(token)
+	claims, err := JwtToIdentityClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
 	teams := ExtractTeams(claims)
 
 	expected := []string{"team-vip-treatment", "team-lunatics"}
@@ -43,6 +53,103 @@ func TestExtractsMultipleTeamsFromListOfGroups(t *testing.T) {
 	}
 }
 
+func TestExtractTeamsWithClaimMappings(t *testing.T) {
+	keycloak := "https://keycloak.k8s.lab.blue.bisnode.net/realms/lab"
+	conf := &Config{Claims: map[string]*ClaimMapping{
+		keycloak: {
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "realm_access.roles",
+			TeamPrefix:    "Team_",
+			TeamRewrites:  []Rewrite{{Pattern: "_", Replacement: "-"}, {Pattern: "^", Replacement: "team-"}},
+		},
+		"https://groups.example.com": {TeamPattern: `^/teams/([a-z-]+)$`},
+		"https://all.example.com":    {TeamPattern: `^team-[a-z-]+$`},
+	}}
+	tests := []struct {
+		name             string
+		claims           jwt.MapClaims
+		expectedUsername string
+		expectedTeams    []string
+	}{
+		{
+			name: "default mapping",
+			claims: jwt.MapClaims{"iss": "https://login.bisnode.com", "email": "bobby@bisnode.com",
+				"groups": []string{"sec-tbac-team-lunatics", "Sec-Tbac-Team-Cool-Runners", "sec-team-ignored"}},
+			expectedUsername: "bobby@bisnode.com",
+			expectedTeams:    []string{"team-lunatics", "team-cool-runners"},
+		},
+		{
+			name: "nested groups claim, prefix and rewrites",
+			claims: jwt.MapClaims{"iss": keycloak, "preferred_username": "bobby", "email": "bobby@bisnode.com",
+				"realm_access": map[string]interface{}{"roles": []string{"Team_cool_runners", "offline_access"}}},
+			expectedUsername: "bobby",
+			expectedTeams:    []string{"team-cool-runners"},
+		},
+		{
+			name: "pattern with submatch",
+			claims: jwt.MapClaims{"iss": "https://groups.example.com", "email": "bobby@bisnode.com",
+				"groups": []string{"/teams/vip-treatment", "/admins"}},
+			expectedUsername: "bobby@bisnode.com",
+			expectedTeams:    []string{"vip-treatment"},
+		},
+		{
+			name: "pattern without submatch, and groups as a single string",
+			claims: jwt.MapClaims{"iss": "https://all.example.com", "email": "bobby@bisnode.com",
+				"groups": "team-lunatics"},
+			expectedUsername: "bobby@bisnode.com",
+			expectedTeams:    []string{"team-lunatics"},
+		},
+		{
+			name:             "missing groups",
+			claims:           jwt.MapClaims{"iss": keycloak, "preferred_username": "bobby"},
+			expectedUsername: "bobby",
+			expectedTeams:    []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, test.claims).SignedString([]byte("much-valid"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := ParseIdentityClaims(token, conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Username != test.expectedUsername {
+				t.Errorf("Expected username %v but was %v", test.expectedUsername, claims.Username)
+			}
+			if teams := ExtractTeams(claims); !reflect.DeepEqual(test.expectedTeams, teams) {
+				t.Errorf("Expected teams to be %v but was %v", test.expectedTeams, teams)
+			}
+		})
+	}
+}
+
+func TestTeamGroup(t *testing.T) {
+	groups := []string{"sec-team-ignored", "Sec-Tbac-Team-Lunatics", "sec-tbac-team-cool-runners"}
+	group, err := (ClaimMapping{}).TeamGroup(groups, "team-lunatics")
+	if err != nil || group != "Sec-Tbac-Team-Lunatics" {
+		t.Errorf("Expected group of team-lunatics, got %q (%v)", group, err)
+	}
+	if group, _ := (ClaimMapping{}).TeamGroup(groups, "team-vip-treatment"); group != "" {
+		t.Errorf("Expected no group for team not a member of, got %q", group)
+	}
+}
+
+func TestInvalidTeamPatternIsAnError(t *testing.T) {
+	if _, err := (ClaimMapping{TeamPattern: "team-("}).Teams([]string{"team-lunatics"}); err == nil {
+		t.Error("Expected invalid team pattern to fail")
+	}
+}
+
+func TestJwtToIdentityClaimsDoesNotLeakInvalidToken(t *testing.T) {
+	_, err := JwtToIdentityClaims("not-a-token.secret-payload.secret-signature")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected error without the token, got %v", err)
+	}
+}
+
 func issueTestToken(user string, groups []string) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"email":  user,
@@ -58,3 +165,89 @@ func issueTestToken(user string, groups []string) string {
 
 	return tokenEncoded
 }
+
+func TestReadConfigFromContextSearchesAllKubeconfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	for file, context := range map[string]string{"first": "some-context", "second": "tr.k8s.qa.blue.bisnode.net"} {
+		conf := api.NewConfig()
+		conf.Clusters[context] = &api.Cluster{Server: "https://api." + context}
+		conf.AuthInfos[context] = &api.AuthInfo{}
+		conf.Contexts[context] = &api.Context{Cluster: context, AuthInfo: context}
+		if err := clientcmd.WriteToFile(*conf, filepath.Join(dir, file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "first")+string(filepath.ListSeparator)+filepath.Join(dir, "second"))
+
+	conf, err := ReadConfigFromContext("tr.k8s.qa.blue.bisnode.net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Clusters["tr.k8s.qa.blue.bisnode.net"] == nil || conf.Contexts["some-context"] == nil {
+		t.Errorf("Expected contexts of all KUBECONFIG files, got %v", conf.Contexts)
+	}
+}
+
+func TestConfigureEnvironments(t *testing.T) {
+	builtin := map[string]*Environment{}
+	for name, e := range environments {
+		copied := *e
+		builtin[name] = &copied
+	}
+	defer func() { environments = builtin }()
+
+	err := ConfigureEnvironments(map[string]*EnvConfig{
+		"test": {Account: "green", Default: true, Issuer: "https://test-login.bisnode.com"},
+		"sandbox": {Context: "sandbox.example.com", Server: "https://sandbox.example.com:6443",
+			Issuer:            "https://keycloak.example.com/realms/sandbox",
+			AuthorizeEndpoint: "https://keycloak.example.com/auth"},
+		"qa":       {Server: "https://qa.example.com"},
+		"prod":     {Context: "prod-cluster"},
+		"nowhere":  {Account: "green"},
+		"nobodies": {Context: "nobodies.example.com"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "nobodies, nowhere") {
+		t.Errorf("Expected environments without issuer to be refused, got %v", err)
+	}
+	if _, ok := LookupEnvironment("nowhere"); ok {
+		t.Error("Expected environment without issuer not to be known")
+	}
+
+	if ctx := EnvToContext("test"); ctx != "tr.k8s.test.green.bisnode.net" {
+		t.Errorf("Expected context of test to be named by account, got %v", ctx)
+	}
+	if env := ContextToEnv("sandbox.example.com"); env != "sandbox" {
+		t.Errorf("Expected configured context of sandbox to be known, got %v", env)
+	}
+	if server := ClusterServer("tr.k8s.qa.blue.bisnode.net"); server != "https://qa.example.com" {
+		t.Errorf("Expected configured server of qa, got %v", server)
+	}
+	if e, _ := LookupEnvironment("dev"); e.Default {
+		t.Error("Expected configured default environment to replace dev")
+	}
+	if e, _ := LookupEnvironment("test"); !e.Default {
+		t.Error("Expected test to be the default environment")
+	}
+
+	expected := Issuer{Name: "https://test-login.bisnode.com",
+		AuthorizeEndpoint: "https://test-login.bisnode.com/as/authorization.oauth2"}
+	if issuer, _ := ClusterIssuer(EnvToContext("test")); issuer != expected {
+		t.Errorf("Expected configured issuer of test with default authorize endpoint, got %+v", issuer)
+	}
+	issuer, _ := ClusterIssuer("sandbox.example.com")
+	if issuer.AuthorizeEndpoint != "https://keycloak.example.com/auth" {
+		t.Errorf("Expected configured authorize endpoint of sandbox, got %+v", issuer)
+	}
+	if issuer, _ := ClusterIssuer(EnvToContext("qa") + "/team-x"); issuer.Name != "https://qa-login.bisnode.com" {
+		t.Errorf("Expected built in issuer of qa for its team context, got %+v", issuer)
+	}
+	if issuer, _ := ClusterIssuer(EnvToContext("prod")); issuer.Name != "https://login.bisnode.com" {
+		t.Errorf("Expected built in issuer of prod with a configured context, got %+v", issuer)
+	}
+	if ClusterCaCert("prod-cluster") != prodCaCert {
+		t.Error("Expected built in CA of prod with a configured context")
+	}
+	if _, err = ClusterIssuer("tr.k8s.prod.orange.bisnode.net"); err == nil {
+		t.Error("Expected no issuer for the replaced context of prod")
+	}
+}